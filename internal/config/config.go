@@ -0,0 +1,545 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrConfigNotFound возвращается, если файл конфигурации не найден ни в одной из родительских директорий
+var ErrConfigNotFound = errors.New("aifr config not found")
+
+// configFiles содержит имена файлов конфигурации в порядке приоритета внутри одной директории
+var configFiles = []string{"aifr.yaml", "aifr.yml", "aifr.json"}
+
+// Task описывает именованную задачу из конфигурации
+type Task struct {
+	Command string            `yaml:"command" json:"command"`
+	Cwd     string            `yaml:"cwd" json:"cwd"`
+	Env     map[string]string `yaml:"env" json:"env"`
+	Timeout string            `yaml:"timeout" json:"timeout"`
+	Needs   []string          `yaml:"needs" json:"needs"`
+	Shell   *bool             `yaml:"shell" json:"shell"`
+	Retries *int              `yaml:"retries" json:"retries"`
+	// MaxLines и MaxTokens ограничивают вывод задачи в отчете
+	MaxLines  *int `yaml:"max_lines" json:"max_lines"`
+	MaxTokens *int `yaml:"max_tokens" json:"max_tokens"`
+	// EnvFile - .env файлы относительно директории конфигурации, EnvAllow и CleanEnv ограничивают наследуемое окружение
+	EnvFile  StringList `yaml:"env_file" json:"env_file"`
+	EnvAllow []string   `yaml:"env_allow" json:"env_allow"`
+	CleanEnv bool       `yaml:"clean_env" json:"clean_env"`
+}
+
+// StringList - список строк, который можно записать и одной строкой: "env_file: .env"
+type StringList []string
+
+// UnmarshalYAML принимает строку или список строк
+func (l *StringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var value string
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		*l = StringList{value}
+		return nil
+	}
+	return node.Decode((*[]string)(l))
+}
+
+// UnmarshalJSON принимает строку или список строк
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	if isJSONString(data) {
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		*l = StringList{value}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(l))
+}
+
+// Preset описывает набор задач и флагов, запускаемый по одному имени
+type Preset struct {
+	Tasks []string               `yaml:"tasks" json:"tasks"`
+	Flags map[string]interface{} `yaml:"flags" json:"flags"`
+}
+
+// Config содержит задачи, пресеты и значения флагов по умолчанию
+type Config struct {
+	Flags   map[string]interface{} `yaml:"flags" json:"flags"`
+	Tasks   map[string]Task        `yaml:"tasks" json:"tasks"`
+	Presets map[string]Preset      `yaml:"presets" json:"presets"`
+	Path    string                 `yaml:"-" json:"-"` // путь к файлу, из которого загружена конфигурация
+	Dir     string                 `yaml:"-" json:"-"` // директория конфигурации, относительно нее задается cwd задач
+}
+
+// UnmarshalYAML позволяет записывать задачу строкой: "lint: yarn lint"
+func (t *Task) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&t.Command)
+	}
+
+	type plain Task
+	return decodeStrictNode(node, (*plain)(t))
+}
+
+// UnmarshalJSON позволяет записывать задачу строкой: "lint": "yarn lint"
+func (t *Task) UnmarshalJSON(data []byte) error {
+	if isJSONString(data) {
+		return json.Unmarshal(data, &t.Command)
+	}
+
+	type plain Task
+	return decodeStrictJSON(data, (*plain)(t))
+}
+
+// UnmarshalYAML позволяет записывать пресет списком задач: "check: [lint, typecheck]"
+func (p *Preset) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		return node.Decode(&p.Tasks)
+	}
+
+	type plain Preset
+	return decodeStrictNode(node, (*plain)(p))
+}
+
+// UnmarshalJSON позволяет записывать пресет списком задач: "check": ["lint", "typecheck"]
+func (p *Preset) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		return json.Unmarshal(data, &p.Tasks)
+	}
+
+	type plain Preset
+	return decodeStrictJSON(data, (*plain)(p))
+}
+
+// Find ищет ближайший файл конфигурации, поднимаясь от dir к корню файловой системы;
+// package.json учитывается, только если в нем есть ключ "aifr"
+func Find(dir string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		for _, name := range configFiles {
+			candidate := filepath.Join(absDir, name)
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+				return candidate, nil
+			}
+		}
+
+		// package.json, который не удалось прочитать, пропускается: он не может быть конфигурацией,
+		// и сломанный манифест в родительской директории не должен ломать каждый запуск
+		candidate := filepath.Join(absDir, "package.json")
+		if _, ok, err := readPackageJSONConfig(candidate); err == nil && ok {
+			return candidate, nil
+		}
+
+		parent := filepath.Dir(absDir)
+		if parent == absDir {
+			return "", ErrConfigNotFound
+		}
+		absDir = parent
+	}
+}
+
+// Discover находит и загружает конфигурацию для dir; возвращает nil без ошибки, если конфигурации нет
+func Discover(dir string) (*Config, error) {
+	path, err := Find(dir)
+	if errors.Is(err, ErrConfigNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return Load(path)
+}
+
+// Load читает и проверяет файл конфигурации: YAML, JSON или ключ "aifr" в package.json
+func Load(path string) (*Config, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+
+	switch {
+	case filepath.Base(absPath) == "package.json":
+		raw, ok, err := readPackageJSONConfig(absPath)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("%s has no \"aifr\" key", path)
+		}
+		if err := decodeStrictJSON(raw, cfg); err != nil {
+			return nil, fmt.Errorf("invalid config %s: %w", path, err)
+		}
+	case strings.HasSuffix(absPath, ".json"):
+		data, err := os.ReadFile(absPath)
+		if err != nil {
+			return nil, err
+		}
+		if err := decodeStrictJSON(data, cfg); err != nil {
+			return nil, fmt.Errorf("invalid config %s: %w", path, err)
+		}
+	default:
+		data, err := os.ReadFile(absPath)
+		if err != nil {
+			return nil, err
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("invalid config %s: %w", path, err)
+		}
+	}
+
+	cfg.Path = absPath
+	cfg.Dir = filepath.Dir(absPath)
+
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// validate проверяет задачи и ссылки пресетов на них
+func (c *Config) validate() error {
+	for _, name := range sortedKeys(c.Tasks) {
+		if name == "" || strings.ContainsAny(name, " \t,") {
+			return fmt.Errorf("invalid task name %q", name)
+		}
+		if _, err := c.taskSpec(name); err != nil {
+			return err
+		}
+		for _, need := range c.Tasks[name].Needs {
+			if _, ok := c.Tasks[need]; !ok {
+				return fmt.Errorf("task %q needs unknown task %q", name, need)
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(c.Presets) {
+		if _, ok := c.Tasks[name]; ok {
+			return fmt.Errorf("preset %q has the same name as a task", name)
+		}
+		preset := c.Presets[name]
+		if len(preset.Tasks) == 0 {
+			return fmt.Errorf("preset %q has no tasks", name)
+		}
+		for _, task := range preset.Tasks {
+			if _, ok := c.Tasks[task]; !ok {
+				return fmt.Errorf("preset %q references unknown task %q", name, task)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Expand раскрывает имена пресетов в имена задач и возвращает значения флагов:
+// флаги пресетов перекрывают флаги верхнего уровня, более поздний пресет перекрывает предыдущий
+func (c *Config) Expand(args []string) ([]string, map[string]interface{}) {
+	flags := map[string]interface{}{}
+	for name, value := range c.Flags {
+		flags[name] = value
+	}
+
+	expanded := make([]string, 0, len(args))
+	for _, arg := range args {
+		preset, ok := c.Presets[arg]
+		if !ok {
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		for _, task := range preset.Tasks {
+			if !contains(expanded, task) {
+				expanded = append(expanded, task)
+			}
+		}
+		for name, value := range preset.Flags {
+			flags[name] = value
+		}
+	}
+
+	return expanded, flags
+}
+
+// ApplyTasks подставляет задачи конфигурации вместо команд с совпадающими именами;
+// опции из аргумента CLI перекрывают опции задачи, а недостающие зависимости добавляются в конец списка
+func (c *Config) ApplyTasks(specs []types.CommandSpec) ([]types.CommandSpec, error) {
+	result := make([]types.CommandSpec, 0, len(specs))
+	included := map[string]bool{}
+
+	for _, spec := range specs {
+		if _, ok := c.Tasks[spec.Command]; !ok {
+			result = append(result, spec)
+			continue
+		}
+
+		task, err := c.taskSpec(spec.Command)
+		if err != nil {
+			return nil, err
+		}
+		included[spec.Command] = true
+		result = append(result, mergeSpec(task, spec))
+	}
+
+	// Зависимости задач добавляются транзитивно, чтобы "aifr test" сам запускал build
+	for i := 0; i < len(result); i++ {
+		for _, need := range result[i].Needs {
+			if included[need] || hasRef(result, need) {
+				continue
+			}
+			if _, ok := c.Tasks[need]; !ok {
+				continue
+			}
+
+			task, err := c.taskSpec(need)
+			if err != nil {
+				return nil, err
+			}
+			included[need] = true
+			result = append(result, task)
+		}
+	}
+
+	return result, nil
+}
+
+// taskSpec превращает задачу конфигурации в CommandSpec
+func (c *Config) taskSpec(name string) (types.CommandSpec, error) {
+	task := c.Tasks[name]
+
+	if strings.TrimSpace(task.Command) == "" {
+		return types.CommandSpec{}, fmt.Errorf("task %q has no command", name)
+	}
+
+	spec := types.CommandSpec{
+		Name:    name,
+		Command: task.Command,
+		Needs:   append([]string(nil), task.Needs...),
+		Dir:     c.Dir,
+	}
+
+	if task.Cwd != "" {
+		spec.Dir = task.Cwd
+		if !filepath.IsAbs(spec.Dir) {
+			spec.Dir = filepath.Join(c.Dir, task.Cwd)
+		}
+	}
+
+	if task.Timeout != "" {
+		timeout, err := time.ParseDuration(task.Timeout)
+		if err != nil || timeout <= 0 {
+			return types.CommandSpec{}, fmt.Errorf("task %q has invalid timeout %q", name, task.Timeout)
+		}
+		spec.Timeout = timeout
+	}
+
+	if task.Retries != nil {
+		if *task.Retries < 0 {
+			return types.CommandSpec{}, fmt.Errorf("task %q has negative retries", name)
+		}
+		retries := *task.Retries
+		spec.Retries = &retries
+	}
+
+	if task.MaxLines != nil {
+		if *task.MaxLines < 0 {
+			return types.CommandSpec{}, fmt.Errorf("task %q has negative max_lines", name)
+		}
+		maxLines := *task.MaxLines
+		spec.MaxOutputLines = &maxLines
+	}
+
+	if task.MaxTokens != nil {
+		if *task.MaxTokens < 0 {
+			return types.CommandSpec{}, fmt.Errorf("task %q has negative max_tokens", name)
+		}
+		maxTokens := *task.MaxTokens
+		spec.MaxOutputTokens = &maxTokens
+	}
+
+	if task.Shell != nil {
+		spec.Shell = types.ShellNever
+		if *task.Shell {
+			spec.Shell = types.ShellAlways
+		}
+	}
+
+	for _, key := range sortedKeys(task.Env) {
+		spec.Env = append(spec.Env, key+"="+task.Env[key])
+	}
+
+	for _, path := range task.EnvFile {
+		if strings.TrimSpace(path) == "" {
+			return types.CommandSpec{}, fmt.Errorf("task %q has an empty env_file", name)
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(spec.Dir, path)
+		}
+		spec.EnvFiles = append(spec.EnvFiles, path)
+	}
+	spec.EnvAllow = append([]string(nil), task.EnvAllow...)
+	spec.CleanEnv = task.CleanEnv
+
+	return spec, nil
+}
+
+// mergeSpec накладывает опции аргумента CLI на задачу конфигурации
+func mergeSpec(task, override types.CommandSpec) types.CommandSpec {
+	if override.Name != "" {
+		task.Name = override.Name
+	}
+	if override.Shell != types.ShellAuto {
+		task.Shell = override.Shell
+	}
+	if override.Timeout > 0 {
+		task.Timeout = override.Timeout
+	}
+	if override.Dir != "" {
+		task.Dir = override.Dir
+	}
+	task.Env = append(task.Env, override.Env...)
+	task.EnvFiles = append(task.EnvFiles, override.EnvFiles...)
+	task.EnvAllow = append(task.EnvAllow, override.EnvAllow...)
+	task.CleanEnv = task.CleanEnv || override.CleanEnv
+	if override.Retries != nil {
+		task.Retries = override.Retries
+	}
+	if override.MaxOutputLines != nil {
+		task.MaxOutputLines = override.MaxOutputLines
+	}
+	if override.MaxOutputTokens != nil {
+		task.MaxOutputTokens = override.MaxOutputTokens
+	}
+	for _, need := range override.Needs {
+		if !contains(task.Needs, need) {
+			task.Needs = append(task.Needs, need)
+		}
+	}
+	return task
+}
+
+// readPackageJSONConfig возвращает значение ключа "aifr" из package.json, если файл и ключ существуют
+// RestoreEnv подставляет значения переменных в команду из last-run.json, где сохранены только имена.
+// Значения берутся из env задачи с тем же именем в текущей конфигурации; переменную, заданную опцией env
+// в CLI, восстановить нельзя, и команда не запускается с неполным окружением
+func (c *Config) RestoreEnv(spec types.CommandSpec) (types.CommandSpec, error) {
+	if len(spec.Env) == 0 {
+		return spec, nil
+	}
+
+	var task Task
+	ok := false
+	if c != nil {
+		task, ok = c.Tasks[spec.Name]
+	}
+
+	env := make([]string, len(spec.Env))
+	for i, name := range spec.Env {
+		value, found := task.Env[name]
+		if !ok || !found || countOf(spec.Env, name) > 1 {
+			return spec, fmt.Errorf("cannot restore env %s of %q: values are not saved in the last run and it is not set by a config task; run the command again with aifr", name, spec.Ref())
+		}
+		env[i] = name + "=" + value
+	}
+	spec.Env = env
+
+	return spec, nil
+}
+
+func readPackageJSONConfig(path string) (json.RawMessage, bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var pkg struct {
+		Aifr json.RawMessage `json:"aifr"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, false, fmt.Errorf("invalid %s: %w", path, err)
+	}
+
+	return pkg.Aifr, len(pkg.Aifr) > 0, nil
+}
+
+func decodeStrictJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+func decodeStrictNode(node *yaml.Node, v interface{}) error {
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	return decoder.Decode(v)
+}
+
+func isJSONString(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && trimmed[0] == '"'
+}
+
+func hasRef(specs []types.CommandSpec, name string) bool {
+	for _, spec := range specs {
+		if spec.Ref() == name {
+			return true
+		}
+	}
+	return false
+}
+
+func countOf(values []string, value string) int {
+	count := 0
+	for _, v := range values {
+		if v == value {
+			count++
+		}
+	}
+	return count
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,356 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll(%q) failed: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile(%q) failed: %v", path, err)
+	}
+}
+
+const yamlConfig = `
+flags:
+  threads: 4
+  output: full
+tasks:
+  lint: yarn lint
+  build:
+    command: go build ./...
+    timeout: 2m
+    max_lines: 100
+  test:
+    command: go test ./...
+    cwd: backend
+    env:
+      CGO_ENABLED: "0"
+      GOFLAGS: -count=1
+    needs: [build]
+    shell: true
+presets:
+  check: [lint, test]
+  ci:
+    tasks: [lint, build]
+    flags:
+      output: errors
+      fail-fast: true
+`
+
+func TestLoad_Formats(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		wantTask string
+	}{
+		{"yaml", "aifr.yaml", yamlConfig, "go build ./..."},
+		{"json", "aifr.json", `{"tasks": {"build": {"command": "go build ./...", "timeout": "2m"}}, "presets": {"ci": ["build"]}}`, "go build ./..."},
+		{"ключ aifr в package.json", "package.json", `{"name": "x", "aifr": {"tasks": {"build": "go build ./..."}}}`, "go build ./..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, tt.file)
+			writeFile(t, path, tt.content)
+
+			cfg, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if cfg.Tasks["build"].Command != tt.wantTask {
+				t.Errorf("build command = %q, want %q", cfg.Tasks["build"].Command, tt.wantTask)
+			}
+			if cfg.Dir != dir {
+				t.Errorf("Dir = %q, want %q", cfg.Dir, dir)
+			}
+		})
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"неизвестное поле", "tasks:\n  lint:\n    comand: yarn lint\n", "comand"},
+		{"задача без команды", "tasks:\n  lint:\n    cwd: web\n", `task "lint" has no command`},
+		{"неверный таймаут", "tasks:\n  lint:\n    command: yarn lint\n    timeout: soon\n", `invalid timeout "soon"`},
+		{"отрицательный лимит вывода", "tasks:\n  lint:\n    command: yarn lint\n    max_tokens: -1\n", "negative max_tokens"},
+		{"неизвестная зависимость", "tasks:\n  lint:\n    command: yarn lint\n    needs: [build]\n", `needs unknown task "build"`},
+		{"пресет с неизвестной задачей", "tasks:\n  lint: yarn lint\npresets:\n  ci: [lint, e2e]\n", `references unknown task "e2e"`},
+		{"пресет совпадает с задачей", "tasks:\n  lint: yarn lint\npresets:\n  lint: [lint]\n", "same name as a task"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "aifr.yaml")
+			writeFile(t, path, tt.content)
+
+			_, err := Load(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFind_WalksUp(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "aifr.yaml"), "tasks:\n  lint: yarn lint\n")
+	// package.json без ключа "aifr" не останавливает поиск
+	writeFile(t, filepath.Join(root, "packages", "web", "package.json"), `{"name": "web"}`)
+
+	path, err := Find(filepath.Join(root, "packages", "web"))
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if path != filepath.Join(root, "aifr.yaml") {
+		t.Errorf("Find() = %q, want %q", path, filepath.Join(root, "aifr.yaml"))
+	}
+
+	writeFile(t, filepath.Join(root, "packages", "api", "package.json"), `{"aifr": {"tasks": {"lint": "go vet ./..."}}}`)
+	path, err = Find(filepath.Join(root, "packages", "api"))
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if path != filepath.Join(root, "packages", "api", "package.json") {
+		t.Errorf("Find() = %q, want nearest package.json with aifr key", path)
+	}
+}
+
+func TestDiscover_SkipsMalformedPackageJSON(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "package.json"), `{"name": "broken",`)
+	nested := filepath.Join(root, "app")
+	writeFile(t, filepath.Join(nested, "main.go"), "package main\n")
+
+	cfg, err := Discover(nested)
+	if err != nil {
+		t.Fatalf("Discover() error = %v, want malformed parent package.json to be skipped", err)
+	}
+	if cfg != nil {
+		t.Errorf("Discover() = %+v, want nil without config", cfg)
+	}
+
+	if _, err := Load(filepath.Join(root, "package.json")); err == nil {
+		t.Error("Load() of a malformed package.json should still fail")
+	}
+}
+
+func TestDiscover_NotFound(t *testing.T) {
+	cfg, err := Discover(t.TempDir())
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	if cfg != nil {
+		t.Errorf("Discover() = %+v, want nil without config", cfg)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aifr.yaml")
+	writeFile(t, path, yamlConfig)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	args, flags := cfg.Expand([]string{"check", "ci", "echo extra"})
+
+	wantArgs := []string{"lint", "test", "build", "echo extra"}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %v, want %v", args, wantArgs)
+	}
+
+	if flags["threads"] != 4 {
+		t.Errorf("threads = %v, want 4 from top-level flags", flags["threads"])
+	}
+	if flags["output"] != "errors" {
+		t.Errorf("output = %v, want preset value %q", flags["output"], "errors")
+	}
+	if flags["fail-fast"] != true {
+		t.Errorf("fail-fast = %v, want true", flags["fail-fast"])
+	}
+}
+
+func TestApplyTasks(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "aifr.yaml")
+	writeFile(t, path, yamlConfig)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	specs, err := cfg.ApplyTasks([]types.CommandSpec{
+		{Command: "test", Timeout: 30 * time.Second},
+		{Command: "echo extra"},
+	})
+	if err != nil {
+		t.Fatalf("ApplyTasks() error = %v", err)
+	}
+
+	maxLines := 100
+	want := []types.CommandSpec{
+		{
+			Name:    "test",
+			Command: "go test ./...",
+			Shell:   types.ShellAlways,
+			Timeout: 30 * time.Second,
+			Needs:   []string{"build"},
+			Dir:     filepath.Join(dir, "backend"),
+			Env:     []string{"CGO_ENABLED=0", "GOFLAGS=-count=1"},
+		},
+		{Command: "echo extra"},
+		{
+			Name:           "build",
+			Command:        "go build ./...",
+			Timeout:        2 * time.Minute,
+			Dir:            dir,
+			MaxOutputLines: &maxLines,
+		},
+	}
+
+	if len(specs) != len(want) {
+		t.Fatalf("ApplyTasks() returned %d specs, want %d: %+v", len(specs), len(want), specs)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(specs[i], want[i]) {
+			t.Errorf("spec[%d] = %+v, want %+v", i, specs[i], want[i])
+		}
+	}
+}
+
+func TestApplyTasks_Environment(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "aifr.yaml")
+	writeFile(t, path, `
+tasks:
+  api:
+    command: yarn test
+    cwd: packages/api
+    env_file: .env.test
+    env_allow: [HOME, NPM_*]
+  web:
+    command: yarn test
+    env_file: [.env, /etc/web.env]
+    clean_env: true
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	specs, err := cfg.ApplyTasks([]types.CommandSpec{
+		{Command: "api", Dir: "packages/other", Env: []string{"DEBUG=1"}, EnvFiles: []string{".env.local"}},
+		{Command: "web"},
+	})
+	if err != nil {
+		t.Fatalf("ApplyTasks() error = %v", err)
+	}
+
+	want := []types.CommandSpec{
+		{
+			Name:     "api",
+			Command:  "yarn test",
+			Dir:      "packages/other",
+			Env:      []string{"DEBUG=1"},
+			EnvFiles: []string{filepath.Join(dir, "packages", "api", ".env.test"), ".env.local"},
+			EnvAllow: []string{"HOME", "NPM_*"},
+		},
+		{
+			Name:     "web",
+			Command:  "yarn test",
+			Dir:      dir,
+			EnvFiles: []string{filepath.Join(dir, ".env"), "/etc/web.env"},
+			CleanEnv: true,
+		},
+	}
+
+	for i := range want {
+		if !reflect.DeepEqual(specs[i], want[i]) {
+			t.Errorf("spec[%d] = %+v, want %+v", i, specs[i], want[i])
+		}
+	}
+}
+
+func TestRestoreEnv(t *testing.T) {
+	cfg := &Config{Tasks: map[string]Task{
+		"test": {Command: "yarn test", Env: map[string]string{"NODE_ENV": "test", "TOKEN": "secret"}},
+	}}
+
+	tests := []struct {
+		name    string
+		cfg     *Config
+		spec    types.CommandSpec
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "значения из задачи",
+			cfg:  cfg,
+			spec: types.CommandSpec{Name: "test", Command: "yarn test", Env: []string{"NODE_ENV", "TOKEN"}},
+			want: []string{"NODE_ENV=test", "TOKEN=secret"},
+		},
+		{
+			name: "команда без env",
+			cfg:  nil,
+			spec: types.CommandSpec{Command: "echo ok"},
+		},
+		{
+			name:    "env из CLI без задачи",
+			cfg:     cfg,
+			spec:    types.CommandSpec{Command: "echo ok", Env: []string{"DEBUG"}},
+			wantErr: true,
+		},
+		{
+			name:    "env из CLI поверх задачи",
+			cfg:     cfg,
+			spec:    types.CommandSpec{Name: "test", Command: "yarn test", Env: []string{"NODE_ENV", "TOKEN", "DEBUG"}},
+			wantErr: true,
+		},
+		{
+			name:    "env из CLI перекрывает переменную задачи",
+			cfg:     cfg,
+			spec:    types.CommandSpec{Name: "test", Command: "yarn test", Env: []string{"NODE_ENV", "TOKEN", "NODE_ENV"}},
+			wantErr: true,
+		},
+		{
+			name:    "конфигурация не найдена",
+			cfg:     nil,
+			spec:    types.CommandSpec{Name: "test", Command: "yarn test", Env: []string{"NODE_ENV"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cfg.RestoreEnv(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RestoreEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got.Env, tt.want) {
+				t.Errorf("RestoreEnv() Env = %v, want %v", got.Env, tt.want)
+			}
+		})
+	}
+}
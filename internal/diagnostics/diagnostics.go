@@ -0,0 +1,105 @@
+package diagnostics
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severity - уровень диагностики
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic - одна ошибка или предупреждение инструмента, разобранные из его вывода
+type Diagnostic struct {
+	Tool     string // инструмент, формат которого распознан: tsc, eslint, go, go test, jest, vitest, pytest, rustc
+	File     string
+	Line     int // 0 - строка неизвестна
+	Column   int // 0 - колонка неизвестна
+	Severity string
+	Rule     string // код ошибки, правило линтера или имя теста
+	Message  string
+}
+
+// located - диагностика вместе с номером строки вывода, где она начинается
+type located struct {
+	offset int
+	Diagnostic
+}
+
+// parser разбирает строки вывода в диагностики одного формата
+type parser func(lines []string) []located
+
+var parsers = []parser{
+	parseTSC,
+	parseESLintStylish,
+	parseESLintUnix,
+	parseGo,
+	parseGoTest,
+	parseJest,
+	parseVitest,
+	parsePytest,
+	parseRustc,
+}
+
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// Parse находит в выводе команды диагностики всех известных форматов в порядке их появления.
+// Если формат не распознан, возвращается nil, и отчет показывает вывод как есть
+func Parse(output string) []Diagnostic {
+	if output == "" {
+		return nil
+	}
+
+	output = ansiPattern.ReplaceAllString(output, "")
+	lines := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+
+	found := []located{}
+	for _, parse := range parsers {
+		found = append(found, parse(lines)...)
+	}
+	if len(found) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(found, func(i, j int) bool { return found[i].offset < found[j].offset })
+
+	seen := map[Diagnostic]bool{}
+	diagnostics := []Diagnostic{}
+	for _, item := range found {
+		if seen[item.Diagnostic] {
+			continue
+		}
+		seen[item.Diagnostic] = true
+		diagnostics = append(diagnostics, item.Diagnostic)
+	}
+
+	return diagnostics
+}
+
+// Count возвращает число ошибок и предупреждений
+func Count(diagnostics []Diagnostic) (errors, warnings int) {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == SeverityWarning {
+			warnings++
+		} else {
+			errors++
+		}
+	}
+	return errors, warnings
+}
+
+// Location возвращает позицию в виде file:line:column, опуская неизвестные части
+func (d Diagnostic) Location() string {
+	location := d.File
+	if d.Line > 0 {
+		location += ":" + strconv.Itoa(d.Line)
+		if d.Column > 0 {
+			location += ":" + strconv.Itoa(d.Column)
+		}
+	}
+	return location
+}
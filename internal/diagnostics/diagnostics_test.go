@@ -0,0 +1,206 @@
+package diagnostics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []Diagnostic
+	}{
+		{
+			name: "tsc",
+			output: "src/app.ts(12,5): error TS2322: Type 'string' is not assignable to type 'number'.\n" +
+				"src/util.ts(3,1): error TS6133: 'x' is declared but its value is never read.\n",
+			want: []Diagnostic{
+				{Tool: "tsc", File: "src/app.ts", Line: 12, Column: 5, Severity: "error", Rule: "TS2322", Message: "Type 'string' is not assignable to type 'number'."},
+				{Tool: "tsc", File: "src/util.ts", Line: 3, Column: 1, Severity: "error", Rule: "TS6133", Message: "'x' is declared but its value is never read."},
+			},
+		},
+		{
+			name: "tsc --pretty с цветами",
+			output: "\x1b[96msrc/app.ts\x1b[0m:\x1b[93m12\x1b[0m:\x1b[93m5\x1b[0m - \x1b[91merror\x1b[0m\x1b[90m TS2322: \x1b[0mType 'string' is not assignable to type 'number'.\n\n" +
+				"12   const x: number = 'a';\n" +
+				"\nFound 1 error in src/app.ts:12\n",
+			want: []Diagnostic{
+				{Tool: "tsc", File: "src/app.ts", Line: 12, Column: 5, Severity: "error", Rule: "TS2322", Message: "Type 'string' is not assignable to type 'number'."},
+			},
+		},
+		{
+			name: "eslint stylish",
+			output: "\n/app/src/index.js\n" +
+				"   1:7   error    'unused' is assigned a value but never used  no-unused-vars\n" +
+				"  12:3   warning  Unexpected console statement                 no-console\n" +
+				"\n/app/src/other.js\n" +
+				"  4:10  error  Parsing error: Unexpected token\n" +
+				"\n✖ 3 problems (2 errors, 1 warning)\n",
+			want: []Diagnostic{
+				{Tool: "eslint", File: "/app/src/index.js", Line: 1, Column: 7, Severity: "error", Rule: "no-unused-vars", Message: "'unused' is assigned a value but never used"},
+				{Tool: "eslint", File: "/app/src/index.js", Line: 12, Column: 3, Severity: "warning", Rule: "no-console", Message: "Unexpected console statement"},
+				{Tool: "eslint", File: "/app/src/other.js", Line: 4, Column: 10, Severity: "error", Message: "Parsing error: Unexpected token"},
+			},
+		},
+		{
+			name:   "eslint unix",
+			output: "/app/src/index.js:1:7: 'unused' is assigned a value but never used. [Error/no-unused-vars]\n\n1 problem\n",
+			want: []Diagnostic{
+				{Tool: "eslint", File: "/app/src/index.js", Line: 1, Column: 7, Severity: "error", Rule: "no-unused-vars", Message: "'unused' is assigned a value but never used."},
+			},
+		},
+		{
+			name:   "go build и go vet",
+			output: "# example.com/app\n./main.go:12:5: undefined: foo\n./util.go:3:2: fmt.Printf format %d has arg s of wrong type string\n",
+			want: []Diagnostic{
+				{Tool: "go", File: "./main.go", Line: 12, Column: 5, Severity: "error", Message: "undefined: foo"},
+				{Tool: "go", File: "./util.go", Line: 3, Column: 2, Severity: "error", Message: "fmt.Printf format %d has arg s of wrong type string"},
+			},
+		},
+		{
+			name: "go test",
+			output: "--- FAIL: TestSum (0.00s)\n" +
+				"    sum_test.go:10: Sum(1, 1) = 3, want 2\n" +
+				"--- FAIL: TestDiv (0.00s)\n" +
+				"    div_test.go:22: unexpected error: division by zero\n" +
+				"FAIL\nFAIL\texample.com/app\t0.002s\n",
+			want: []Diagnostic{
+				{Tool: "go test", File: "sum_test.go", Line: 10, Severity: "error", Rule: "TestSum", Message: "Sum(1, 1) = 3, want 2"},
+				{Tool: "go test", File: "div_test.go", Line: 22, Severity: "error", Rule: "TestDiv", Message: "unexpected error: division by zero"},
+			},
+		},
+		{
+			name: "go test -v",
+			output: "=== RUN   TestSum\n" +
+				"    sum_test.go:8: checking 1+1\n" +
+				"    sum_test.go:10: Sum(1, 1) = 3, want 2\n" +
+				"--- FAIL: TestSum (0.00s)\n" +
+				"=== RUN   TestDiv\n" +
+				"    div_test.go:5: dividing\n" +
+				"--- PASS: TestDiv (0.00s)\n" +
+				"FAIL\nFAIL\texample.com/app\t0.002s\n",
+			want: []Diagnostic{
+				{Tool: "go test", File: "sum_test.go", Line: 8, Severity: "error", Rule: "TestSum", Message: "checking 1+1"},
+				{Tool: "go test", File: "sum_test.go", Line: 10, Severity: "error", Rule: "TestSum", Message: "Sum(1, 1) = 3, want 2"},
+			},
+		},
+		{
+			name: "t.Log прошедших тестов и log.Lshortfile",
+			output: "server.go:42: listening on :8080\n" +
+				"=== RUN   TestServe\n" +
+				"    serve_test.go:12: request took 5ms\n" +
+				"--- PASS: TestServe (0.01s)\n" +
+				"PASS\nok  \texample.com/app\t0.012s\n",
+			want: nil,
+		},
+		{
+			name: "jest",
+			output: " FAIL  src/sum.test.ts\n" +
+				"  ● Console\n\n    console.log\n      debug output\n\n" +
+				"  ● sum › adds numbers\n\n" +
+				"    expect(received).toBe(expected) // Object.is equality\n\n" +
+				"    Expected: 2\n    Received: 3\n\n" +
+				"      10 |   it('adds numbers', () => {\n" +
+				"    > 11 |     expect(sum(1, 1)).toBe(2);\n" +
+				"         |                       ^\n\n" +
+				"      at Object.<anonymous> (node_modules/expect/build/index.js:1:1)\n" +
+				"      at Object.<anonymous> (src/sum.test.ts:11:23)\n\n" +
+				"Tests:       1 failed, 1 total\n",
+			want: []Diagnostic{
+				{Tool: "jest", File: "src/sum.test.ts", Line: 11, Column: 23, Severity: "error", Rule: "sum › adds numbers", Message: "expect(received).toBe(expected) // Object.is equality"},
+			},
+		},
+		{
+			name: "vitest",
+			output: " ❯ src/sum.test.ts (2 tests | 1 failed) 5ms\n" +
+				"   × sum > adds numbers\n\n" +
+				"⎯⎯⎯⎯⎯⎯⎯ Failed Tests 1 ⎯⎯⎯⎯⎯⎯⎯\n\n" +
+				" FAIL  src/sum.test.ts > sum > adds numbers\n" +
+				"AssertionError: expected 3 to be 2 // Object.is equality\n" +
+				" ❯ src/sum.test.ts:5:23\n",
+			want: []Diagnostic{
+				{Tool: "vitest", File: "src/sum.test.ts", Line: 5, Column: 23, Severity: "error", Rule: "sum > adds numbers", Message: "AssertionError: expected 3 to be 2 // Object.is equality"},
+			},
+		},
+		{
+			name: "pytest",
+			output: "    def test_get():\n>       assert response.status == 200\nE       assert 404 == 200\n\n" +
+				"tests/test_api.py:12: AssertionError\n" +
+				"=========================== short test summary info ============================\n" +
+				"FAILED tests/test_api.py::test_get - assert 404 == 200\n" +
+				"ERROR tests/test_db.py::test_connect\n",
+			want: []Diagnostic{
+				{Tool: "pytest", File: "tests/test_api.py", Line: 12, Severity: "error", Rule: "test_get", Message: "assert 404 == 200"},
+				{Tool: "pytest", File: "tests/test_db.py", Severity: "error", Rule: "test_connect"},
+			},
+		},
+		{
+			name: "rustc",
+			output: "warning: unused variable: `x`\n --> src/main.rs:2:9\n  |\n2 |     let x = 1;\n\n" +
+				"error[E0308]: mismatched types\n --> src/main.rs:4:18\n  |\n\n" +
+				"error: aborting due to 1 previous error\n",
+			want: []Diagnostic{
+				{Tool: "rustc", File: "src/main.rs", Line: 2, Column: 9, Severity: "warning", Message: "unused variable: `x`"},
+				{Tool: "rustc", File: "src/main.rs", Line: 4, Column: 18, Severity: "error", Rule: "E0308", Message: "mismatched types"},
+			},
+		},
+		{
+			name:   "неизвестный формат",
+			output: "something went wrong\nexit status 1\n",
+			want:   nil,
+		},
+		{
+			name:   "пустой вывод",
+			output: "",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.output)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() =\n%#v\nwant\n%#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Deduplicates(t *testing.T) {
+	output := "./main.go:12:5: undefined: foo\n./main.go:12:5: undefined: foo\n"
+
+	got := Parse(output)
+	if len(got) != 1 {
+		t.Errorf("Expected identical diagnostics to be merged, got %d", len(got))
+	}
+}
+
+func TestDiagnostic_Location(t *testing.T) {
+	tests := []struct {
+		diagnostic Diagnostic
+		want       string
+	}{
+		{Diagnostic{File: "a.ts", Line: 1, Column: 2}, "a.ts:1:2"},
+		{Diagnostic{File: "a_test.go", Line: 10}, "a_test.go:10"},
+		{Diagnostic{File: "tests/test_db.py"}, "tests/test_db.py"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.diagnostic.Location(); got != tt.want {
+			t.Errorf("Location() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	errors, warnings := Count([]Diagnostic{
+		{Severity: SeverityError},
+		{Severity: SeverityWarning},
+		{Severity: SeverityError},
+	})
+
+	if errors != 2 || warnings != 1 {
+		t.Errorf("Count() = %d, %d, want 2, 1", errors, warnings)
+	}
+}
@@ -0,0 +1,345 @@
+package diagnostics
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// src/app.ts(12,5): error TS2322: Type 'string' is not assignable to type 'number'.
+	tscPattern = regexp.MustCompile(`^(\S.*?)\((\d+),(\d+)\): (error|warning) (TS\d+): (.+)$`)
+	// src/app.ts:12:5 - error TS2322: ... (tsc --pretty)
+	tscPrettyPattern = regexp.MustCompile(`^(\S.*?):(\d+):(\d+) - (error|warning) (TS\d+): (.+)$`)
+
+	//   12:5  error  'x' is defined but never used  no-unused-vars
+	eslintStylishPattern = regexp.MustCompile(`^\s+(\d+):(\d+)\s+(error|warning)\s+(.+?)(?:\s{2,}(\S+))?\s*$`)
+	// /app/src/index.js:12:5: 'x' is defined but never used. [Error/no-unused-vars]
+	eslintUnixPattern = regexp.MustCompile(`^(\S.*?):(\d+):(\d+): (.+) \[(Error|Warning)(?:/(\S+))?\]$`)
+
+	// ./main.go:12:5: undefined: foo; колонка обязательна: "server.go:42: listening" из log.Lshortfile - не ошибка
+	goPattern = regexp.MustCompile(`^(\S+\.go):(\d+):(\d+): (.+)$`)
+	//     main_test.go:42: expected 2, got 1
+	goTestPattern = regexp.MustCompile(`^\s+(\S+\.go):(\d+): (.+)$`)
+	// === RUN   TestSum, --- FAIL: TestSum (0.00s), --- PASS: TestSum (0.00s)
+	goTestRunPattern    = regexp.MustCompile(`^\s*=== (?:RUN|CONT)\s+(\S+)`)
+	goTestResultPattern = regexp.MustCompile(`^\s*--- (FAIL|PASS|SKIP):\s+(\S+)`)
+
+	//   ● Suite › test name
+	jestTitlePattern = regexp.MustCompile(`^\s*● (.+)$`)
+	//       at Object.<anonymous> (src/sum.test.ts:11:23)
+	jestLocationPattern = regexp.MustCompile(`^\s+at (?:.*\()?([^()\s]+):(\d+):(\d+)\)?$`)
+
+	//  FAIL  src/sum.test.ts > sum > adds numbers
+	vitestTitlePattern = regexp.MustCompile(`^\s*FAIL\s+(\S+) > (.+)$`)
+	//  ❯ src/sum.test.ts:5:23
+	vitestLocationPattern = regexp.MustCompile(`^\s*❯ (\S+):(\d+):(\d+)$`)
+
+	// FAILED tests/test_api.py::test_get - AssertionError: assert 404 == 200
+	pytestSummaryPattern = regexp.MustCompile(`^(FAILED|ERROR) (\S+?\.py)::(\S+)(?: - (.+))?$`)
+	// tests/test_api.py:12: AssertionError
+	pytestLocationPattern = regexp.MustCompile(`^(\S+\.py):(\d+): \w+`)
+
+	// error[E0308]: mismatched types
+	rustcTitlePattern = regexp.MustCompile(`^(error|warning)(?:\[(\w+)\])?: (.+)$`)
+	//  --> src/main.rs:4:18
+	rustcLocationPattern = regexp.MustCompile(`^\s*--> (\S+):(\d+):(\d+)$`)
+)
+
+func parseTSC(lines []string) []located {
+	found := []located{}
+	for i, line := range lines {
+		match := tscPattern.FindStringSubmatch(line)
+		if match == nil {
+			match = tscPrettyPattern.FindStringSubmatch(line)
+		}
+		if match == nil {
+			continue
+		}
+		found = append(found, located{i, Diagnostic{
+			Tool:     "tsc",
+			File:     match[1],
+			Line:     atoi(match[2]),
+			Column:   atoi(match[3]),
+			Severity: match[4],
+			Rule:     match[5],
+			Message:  strings.TrimSpace(match[6]),
+		}})
+	}
+	return found
+}
+
+// parseESLintStylish разбирает формат eslint по умолчанию: строка с путем файла, затем строки с проблемами
+func parseESLintStylish(lines []string) []located {
+	found := []located{}
+	file := ""
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			file = strings.TrimSpace(line)
+			continue
+		}
+		if file == "" {
+			continue
+		}
+
+		match := eslintStylishPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		found = append(found, located{i, Diagnostic{
+			Tool:     "eslint",
+			File:     file,
+			Line:     atoi(match[1]),
+			Column:   atoi(match[2]),
+			Severity: match[3],
+			Rule:     match[5],
+			Message:  strings.TrimSpace(match[4]),
+		}})
+	}
+	return found
+}
+
+func parseESLintUnix(lines []string) []located {
+	found := []located{}
+	for i, line := range lines {
+		match := eslintUnixPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		found = append(found, located{i, Diagnostic{
+			Tool:     "eslint",
+			File:     match[1],
+			Line:     atoi(match[2]),
+			Column:   atoi(match[3]),
+			Severity: strings.ToLower(match[5]),
+			Rule:     match[6],
+			Message:  strings.TrimSpace(match[4]),
+		}})
+	}
+	return found
+}
+
+// parseGo разбирает ошибки go build и go vet
+func parseGo(lines []string) []located {
+	found := []located{}
+	for i, line := range lines {
+		match := goPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		found = append(found, located{i, Diagnostic{
+			Tool:     "go",
+			File:     match[1],
+			Line:     atoi(match[2]),
+			Column:   atoi(match[3]),
+			Severity: SeverityError,
+			Message:  strings.TrimSpace(match[4]),
+		}})
+	}
+	return found
+}
+
+// parseGoTest разбирает сообщения t.Error/t.Fatal упавших тестов; правилом становится имя теста.
+// Без -v сообщения идут под "--- FAIL", с -v - между "=== RUN" и "--- FAIL"; сообщения t.Log
+// прошедших и пропущенных тестов отбрасываются
+func parseGoTest(lines []string) []located {
+	found := []located{}
+	pending := map[string][]located{}
+	test := ""
+	failing := false
+
+	for i, line := range lines {
+		if match := goTestRunPattern.FindStringSubmatch(line); match != nil {
+			test, failing = match[1], false
+			continue
+		}
+		if match := goTestResultPattern.FindStringSubmatch(line); match != nil {
+			test, failing = match[2], match[1] == "FAIL"
+			if failing {
+				found = append(found, pending[test]...)
+			}
+			delete(pending, test)
+			continue
+		}
+
+		match := goTestPattern.FindStringSubmatch(line)
+		if match == nil {
+			// Строка без отступа ("FAIL", "ok", "PASS") закрывает блок теста
+			if line != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+				test, failing = "", false
+			}
+			continue
+		}
+		if test == "" {
+			continue
+		}
+
+		diagnostic := located{i, Diagnostic{
+			Tool:     "go test",
+			File:     match[1],
+			Line:     atoi(match[2]),
+			Severity: SeverityError,
+			Rule:     test,
+			Message:  strings.TrimSpace(match[3]),
+		}}
+		if failing {
+			found = append(found, diagnostic)
+		} else {
+			pending[test] = append(pending[test], diagnostic)
+		}
+	}
+
+	return found
+}
+
+// parseJest разбирает блоки "● test name": сообщением становится первая строка ошибки,
+// позицией - первый кадр стека вне node_modules. Блоки "● Console" с console.log пропускаются
+func parseJest(lines []string) []located {
+	found := []located{}
+	current := -1
+
+	for i, line := range lines {
+		if match := jestTitlePattern.FindStringSubmatch(line); match != nil {
+			if strings.TrimSpace(match[1]) == "Console" {
+				current = -1
+				continue
+			}
+			current = len(found)
+			found = append(found, located{i, Diagnostic{
+				Tool:     "jest",
+				Severity: SeverityError,
+				Rule:     strings.TrimSpace(match[1]),
+			}})
+			continue
+		}
+		if current < 0 {
+			continue
+		}
+
+		diagnostic := &found[current].Diagnostic
+		trimmed := strings.TrimSpace(line)
+		if diagnostic.Message == "" && trimmed != "" {
+			diagnostic.Message = trimmed
+			continue
+		}
+
+		if diagnostic.File != "" || strings.Contains(line, "node_modules") {
+			continue
+		}
+		if match := jestLocationPattern.FindStringSubmatch(line); match != nil {
+			diagnostic.File = match[1]
+			diagnostic.Line = atoi(match[2])
+			diagnostic.Column = atoi(match[3])
+		}
+	}
+
+	return found
+}
+
+func parseVitest(lines []string) []located {
+	found := []located{}
+	current := -1
+
+	for i, line := range lines {
+		if match := vitestTitlePattern.FindStringSubmatch(line); match != nil {
+			current = len(found)
+			found = append(found, located{i, Diagnostic{
+				Tool:     "vitest",
+				File:     match[1],
+				Severity: SeverityError,
+				Rule:     strings.TrimSpace(match[2]),
+			}})
+			continue
+		}
+		if current < 0 {
+			continue
+		}
+
+		diagnostic := &found[current].Diagnostic
+		trimmed := strings.TrimSpace(line)
+		if diagnostic.Message == "" && trimmed != "" {
+			diagnostic.Message = trimmed
+			continue
+		}
+
+		if diagnostic.Line > 0 {
+			continue
+		}
+		if match := vitestLocationPattern.FindStringSubmatch(line); match != nil && !strings.Contains(match[1], "node_modules") {
+			diagnostic.File = match[1]
+			diagnostic.Line = atoi(match[2])
+			diagnostic.Column = atoi(match[3])
+		}
+	}
+
+	return found
+}
+
+// parsePytest разбирает строки "FAILED file::test - message" из краткой сводки pytest;
+// номер строки берется из последнего кадра traceback в этом файле
+func parsePytest(lines []string) []located {
+	found := []located{}
+	lastLine := map[string]int{}
+
+	for i, line := range lines {
+		if match := pytestLocationPattern.FindStringSubmatch(line); match != nil {
+			lastLine[match[1]] = atoi(match[2])
+			continue
+		}
+
+		match := pytestSummaryPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		found = append(found, located{i, Diagnostic{
+			Tool:     "pytest",
+			File:     match[2],
+			Line:     lastLine[match[2]],
+			Severity: SeverityError,
+			Rule:     match[3],
+			Message:  strings.TrimSpace(match[4]),
+		}})
+	}
+
+	return found
+}
+
+// parseRustc разбирает заголовок "error[E0308]: ..." и следующую за ним строку "--> file:line:col";
+// сообщения без позиции, например "aborting due to previous error", пропускаются
+func parseRustc(lines []string) []located {
+	found := []located{}
+	var pending *located
+
+	for i, line := range lines {
+		if match := rustcTitlePattern.FindStringSubmatch(line); match != nil {
+			pending = &located{i, Diagnostic{
+				Tool:     "rustc",
+				Severity: match[1],
+				Rule:     match[2],
+				Message:  strings.TrimSpace(match[3]),
+			}}
+			continue
+		}
+		if pending == nil {
+			continue
+		}
+
+		if match := rustcLocationPattern.FindStringSubmatch(line); match != nil {
+			pending.File = match[1]
+			pending.Line = atoi(match[2])
+			pending.Column = atoi(match[3])
+			found = append(found, *pending)
+		}
+		pending = nil
+	}
+
+	return found
+}
+
+func atoi(value string) int {
+	number, _ := strconv.Atoi(value)
+	return number
+}
@@ -0,0 +1,200 @@
+package discovery
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/config"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/parser"
+)
+
+// Source описывает, откуда взялась запускаемая задача
+type Source string
+
+const (
+	SourceTask   Source = "task"   // задача из конфигурации aifr
+	SourcePreset Source = "preset" // пресет из конфигурации aifr
+	SourceScript Source = "script" // скрипт из package.json
+	SourceMake   Source = "make"   // цель Makefile
+	SourceBin    Source = "bin"    // бинарник из node_modules/.bin
+)
+
+// Entry описывает одну задачу, которую можно передать aifr
+type Entry struct {
+	Name       string `json:"name"`
+	Source     Source `json:"source"`
+	Command    string `json:"command"`              // команда, которая реально будет выполнена
+	Definition string `json:"definition,omitempty"` // тело скрипта, описание цели или путь к бинарнику
+	File       string `json:"file"`                 // файл или директория, где найдена задача
+}
+
+// makefiles содержит имена Makefile в порядке, в котором их ищет GNU make
+var makefiles = []string{"GNUmakefile", "makefile", "Makefile"}
+
+// Discover собирает задачи конфигурации, скрипты package.json, цели Makefile и бинарники node_modules/.bin для dir
+func Discover(dir string, cfg *config.Config) ([]Entry, error) {
+	entries := []Entry{}
+
+	if cfg != nil {
+		entries = append(entries, configEntries(cfg)...)
+	}
+
+	scripts, err := scriptEntries(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, scripts...)
+
+	targets, err := makeEntries(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, targets...)
+
+	binaries, err := binEntries(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, binaries...)
+
+	return entries, nil
+}
+
+// configEntries возвращает задачи и пресеты конфигурации
+func configEntries(cfg *config.Config) []Entry {
+	entries := []Entry{}
+
+	for _, name := range sortedKeys(cfg.Tasks) {
+		task := cfg.Tasks[name]
+		dir := cfg.Dir
+		if filepath.IsAbs(task.Cwd) {
+			dir = task.Cwd
+		} else if task.Cwd != "" {
+			dir = filepath.Join(cfg.Dir, task.Cwd)
+		}
+
+		entries = append(entries, Entry{
+			Name:       name,
+			Source:     SourceTask,
+			Command:    parser.ResolveCommand(dir, task.Command),
+			Definition: task.Command,
+			File:       cfg.Path,
+		})
+	}
+
+	for _, name := range sortedKeys(cfg.Presets) {
+		entries = append(entries, Entry{
+			Name:       name,
+			Source:     SourcePreset,
+			Command:    "aifr " + name,
+			Definition: strings.Join(cfg.Presets[name].Tasks, ", "),
+			File:       cfg.Path,
+		})
+	}
+
+	return entries
+}
+
+// scriptEntries возвращает скрипты ближайшего package.json
+func scriptEntries(dir string) ([]Entry, error) {
+	pkg, err := parser.ParsePackageJSON(dir)
+	if errors.Is(err, parser.ErrPackageJSONNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	for _, name := range sortedKeys(pkg.Scripts) {
+		entries = append(entries, Entry{
+			Name:       name,
+			Source:     SourceScript,
+			Command:    parser.ResolveCommand(dir, name),
+			Definition: pkg.Scripts[name],
+			File:       filepath.Join(pkg.Dir, "package.json"),
+		})
+	}
+
+	return entries, nil
+}
+
+// makeEntries возвращает цели Makefile из dir
+func makeEntries(dir string) ([]Entry, error) {
+	for _, name := range makefiles {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		absPath, _ := filepath.Abs(path)
+		entries := []Entry{}
+		for _, target := range parseMakeTargets(string(data)) {
+			entries = append(entries, Entry{
+				Name:       target.name,
+				Source:     SourceMake,
+				Command:    "make " + target.name,
+				Definition: target.description,
+				File:       absPath,
+			})
+		}
+		return entries, nil
+	}
+
+	return nil, nil
+}
+
+// binEntries возвращает исполняемые файлы из node_modules/.bin рядом с ближайшим package.json
+func binEntries(dir string) ([]Entry, error) {
+	root := dir
+	if pkg, err := parser.ParsePackageJSON(dir); err == nil {
+		root = pkg.Dir
+	}
+
+	binDir := filepath.Join(root, "node_modules", ".bin")
+	files, err := os.ReadDir(binDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	absBinDir, _ := filepath.Abs(binDir)
+	entries := []Entry{}
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		// Windows-обертки дублируют основной бинарник
+		if ext := filepath.Ext(file.Name()); ext == ".cmd" || ext == ".ps1" {
+			continue
+		}
+
+		entries = append(entries, Entry{
+			Name:       file.Name(),
+			Source:     SourceBin,
+			Command:    parser.BinaryCommand(root, file.Name()),
+			Definition: filepath.Join(absBinDir, file.Name()),
+			File:       absBinDir,
+		})
+	}
+
+	return entries, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
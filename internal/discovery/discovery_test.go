@@ -0,0 +1,123 @@
+package discovery
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/config"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/parser"
+)
+
+func writeFile(t *testing.T, path, content string, perm os.FileMode) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll(%q) failed: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), perm); err != nil {
+		t.Fatalf("WriteFile(%q) failed: %v", path, err)
+	}
+}
+
+func TestParseMakeTargets(t *testing.T) {
+	content := `
+.PHONY: build test
+VERSION := 1.0
+CC = gcc
+GOFLAGS ::= -v
+
+build: deps ## Build the binary
+	@go build ./...
+
+test lint:
+	-go test ./...
+
+%.o: %.c
+	$(CC) -c $<
+
+$(BIN): build
+	cp x y
+
+define HELP
+fake: target
+endef
+
+debug: CFLAGS = -g
+deps:
+`
+
+	want := []makeTarget{
+		{name: "build", description: "Build the binary"},
+		{name: "test", description: "go test ./..."},
+		{name: "lint", description: "go test ./..."},
+		{name: "deps", description: ""},
+	}
+
+	if got := parseMakeTargets(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMakeTargets() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	parser.ClearCache()
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "package.json"), `{"scripts": {"lint": "eslint ."}}`, 0o644)
+	writeFile(t, filepath.Join(dir, "yarn.lock"), "", 0o644)
+	writeFile(t, filepath.Join(dir, "Makefile"), "check:\n\tmake lint\n", 0o644)
+	writeFile(t, filepath.Join(dir, "node_modules", ".bin", "eslint"), "#!/bin/sh\n", 0o755)
+	writeFile(t, filepath.Join(dir, "node_modules", ".bin", "eslint.cmd"), "", 0o644)
+	writeFile(t, filepath.Join(dir, "aifr.yaml"), "tasks:\n  vet: go vet ./...\npresets:\n  ci: [vet]\n", 0o644)
+
+	cfg, err := config.Load(filepath.Join(dir, "aifr.yaml"))
+	if err != nil {
+		t.Fatalf("config.Load() error = %v", err)
+	}
+
+	entries, err := Discover(dir, cfg)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	got := map[Source][2]string{}
+	for _, entry := range entries {
+		got[entry.Source] = [2]string{entry.Name, entry.Command}
+	}
+
+	want := map[Source][2]string{
+		SourceTask:   {"vet", "go vet ./..."},
+		SourcePreset: {"ci", "aifr ci"},
+		SourceScript: {"lint", "yarn run lint"},
+		SourceMake:   {"check", "make check"},
+		SourceBin:    {"eslint", "yarn eslint"},
+	}
+
+	if len(entries) != len(want) {
+		t.Errorf("Discover() returned %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Discover() = %v, want %v", got, want)
+	}
+}
+
+func TestDiscover_AbsoluteTaskCwd(t *testing.T) {
+	parser.ClearCache()
+
+	dir := t.TempDir()
+	api := filepath.Join(t.TempDir(), "api")
+	writeFile(t, filepath.Join(api, "package.json"), `{"scripts": {"test": "jest"}}`, 0o644)
+	writeFile(t, filepath.Join(api, "pnpm-lock.yaml"), "", 0o644)
+	writeFile(t, filepath.Join(dir, "aifr.yaml"), "tasks:\n  api:\n    command: test\n    cwd: "+api+"\n", 0o644)
+
+	cfg, err := config.Load(filepath.Join(dir, "aifr.yaml"))
+	if err != nil {
+		t.Fatalf("config.Load() error = %v", err)
+	}
+
+	entries := configEntries(cfg)
+	if len(entries) != 1 || entries[0].Command != "pnpm run test" {
+		t.Errorf("configEntries() = %+v, want script resolved in absolute cwd", entries)
+	}
+}
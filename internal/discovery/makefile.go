@@ -0,0 +1,111 @@
+package discovery
+
+import (
+	"strings"
+)
+
+// makeTarget описывает цель Makefile и ее краткое описание
+type makeTarget struct {
+	name        string
+	description string
+}
+
+// parseMakeTargets находит явные цели Makefile; описание берется из комментария "## ..." после цели
+// или из первой строки рецепта. Шаблонные, служебные и вычисляемые цели пропускаются
+func parseMakeTargets(content string) []makeTarget {
+	targets := []makeTarget{}
+	index := map[string]int{}
+	current := []int{}
+	inDefine := false
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		trimmed := strings.TrimSpace(line)
+		if inDefine {
+			if trimmed == "endef" {
+				inDefine = false
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "define ") || trimmed == "define" {
+			inDefine = true
+			continue
+		}
+
+		if strings.HasPrefix(line, "\t") {
+			recipe := strings.TrimLeft(trimmed, "@-+")
+			for _, i := range current {
+				if targets[i].description == "" && recipe != "" {
+					targets[i].description = strings.TrimSpace(recipe)
+				}
+			}
+			continue
+		}
+
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		names, rest, ok := splitRule(line)
+		if !ok {
+			current = nil
+			continue
+		}
+
+		description := ""
+		if _, comment, found := strings.Cut(rest, "##"); found {
+			description = strings.TrimSpace(comment)
+		}
+
+		current = nil
+		for _, name := range names {
+			if !isExplicitTarget(name) {
+				continue
+			}
+			if i, exists := index[name]; exists {
+				if targets[i].description == "" {
+					targets[i].description = description
+				}
+				current = append(current, i)
+				continue
+			}
+			index[name] = len(targets)
+			current = append(current, len(targets))
+			targets = append(targets, makeTarget{name: name, description: description})
+		}
+	}
+
+	return targets
+}
+
+// splitRule разбирает строку "a b: deps" в имена целей и остаток; присваивания переменных не считаются правилами
+func splitRule(line string) ([]string, string, bool) {
+	colon := strings.IndexByte(line, ':')
+	if colon <= 0 {
+		return nil, "", false
+	}
+
+	head := line[:colon]
+	if strings.ContainsAny(head, "=#") {
+		return nil, "", false
+	}
+
+	rest := line[colon+1:]
+	if strings.HasPrefix(rest, "=") || strings.HasPrefix(rest, ":=") || strings.HasPrefix(rest, "::=") {
+		return nil, "", false
+	}
+	rest = strings.TrimPrefix(rest, ":")
+
+	// Переменные, заданные для цели ("target: VAR = value"), не объявляют новых целей
+	deps, _, _ := strings.Cut(rest, "#")
+	if strings.Contains(deps, "=") {
+		return nil, "", false
+	}
+
+	return strings.Fields(head), rest, true
+}
+
+func isExplicitTarget(name string) bool {
+	return name != "" && !strings.HasPrefix(name, ".") && !strings.ContainsAny(name, "%$()")
+}
@@ -0,0 +1,144 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+// Type - тип события запуска
+type Type string
+
+const (
+	RunStarted      Type = "run_started"
+	CommandQueued   Type = "command_queued"
+	CommandStarted  Type = "command_started"
+	OutputLine      Type = "output_line"
+	CommandRetrying Type = "command_retrying"
+	CommandFinished Type = "command_finished"
+	RunFinished     Type = "run_finished"
+)
+
+// Имена потоков вывода в событиях OutputLine
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// Event описывает событие запуска; заполняются только поля, относящиеся к его типу
+type Event struct {
+	Type    Type
+	Time    time.Time
+	Index   int // индекс команды в запуске, -1 для событий уровня запуска
+	Command string
+
+	Commands []string // RunStarted
+	Threads  int      // RunStarted
+
+	ResolvedCommand string // CommandStarted
+	Pid             int    // CommandStarted
+
+	Stream  string // OutputLine
+	Line    string // OutputLine
+	Partial bool   // OutputLine: строка не завершена; следующее событие потока с Partial=false дописывает ее, возможно пустым Line
+
+	Attempt int           // CommandRetrying: номер следующей попытки, начиная с 2
+	Delay   time.Duration // CommandRetrying: пауза перед следующей попыткой
+
+	Result  *types.CommandResult  // CommandFinished, CommandRetrying (результат упавшей попытки)
+	Results []types.CommandResult // RunFinished
+}
+
+// Handler обрабатывает событие
+type Handler func(Event)
+
+// Bus рассылает события подписчикам; обработчики вызываются последовательно, поэтому вывод не перемешивается
+type Bus struct {
+	mu       sync.Mutex
+	handlers []Handler
+}
+
+// NewBus создает пустую шину событий
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe добавляет обработчик событий
+func (b *Bus) Subscribe(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, handler)
+}
+
+// HasSubscribers проверяет, есть ли у шины подписчики
+func (b *Bus) HasSubscribers() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.handlers) > 0
+}
+
+// Publish отправляет событие всем подписчикам
+func (b *Bus) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, handler := range b.handlers {
+		handler(event)
+	}
+}
+
+type busKey struct{}
+
+type commandKey struct{}
+
+type commandScope struct {
+	index   int
+	command string
+}
+
+// WithBus возвращает context с шиной событий
+func WithBus(ctx context.Context, bus *Bus) context.Context {
+	return context.WithValue(ctx, busKey{}, bus)
+}
+
+// FromContext возвращает шину событий из context или nil
+func FromContext(ctx context.Context) *Bus {
+	bus, _ := ctx.Value(busKey{}).(*Bus)
+	return bus
+}
+
+// WithCommand привязывает context к команде, чтобы события executor содержали её индекс и имя
+func WithCommand(ctx context.Context, index int, command string) context.Context {
+	return context.WithValue(ctx, commandKey{}, commandScope{index: index, command: command})
+}
+
+// Active проверяет, есть ли в context шина с подписчиками
+func Active(ctx context.Context) bool {
+	bus := FromContext(ctx)
+	return bus != nil && bus.HasSubscribers()
+}
+
+// Publish отправляет событие в шину из context; индекс и имя команды берутся из WithCommand
+func Publish(ctx context.Context, event Event) {
+	bus := FromContext(ctx)
+	if bus == nil {
+		return
+	}
+
+	if scope, ok := ctx.Value(commandKey{}).(commandScope); ok {
+		event.Index = scope.index
+		if event.Command == "" {
+			event.Command = scope.command
+		}
+	}
+
+	bus.Publish(event)
+}
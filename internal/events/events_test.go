@@ -0,0 +1,71 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestPublish_WithoutBus(t *testing.T) {
+	// Публикация без шины в context не должна паниковать
+	Publish(context.Background(), Event{Type: RunStarted})
+
+	if Active(context.Background()) {
+		t.Error("Context without bus should not be active")
+	}
+}
+
+func TestPublish_CommandScope(t *testing.T) {
+	bus := NewBus()
+
+	var received []Event
+	bus.Subscribe(func(event Event) {
+		received = append(received, event)
+	})
+
+	ctx := WithCommand(WithBus(context.Background(), bus), 3, "lint")
+	Publish(ctx, Event{Type: OutputLine, Stream: StreamStdout, Line: "ok"})
+
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(received))
+	}
+
+	event := received[0]
+	if event.Index != 3 || event.Command != "lint" {
+		t.Errorf("Event should inherit command scope, got index=%d command=%q", event.Index, event.Command)
+	}
+	if event.Time.IsZero() {
+		t.Error("Event time should be set on publish")
+	}
+	if !Active(ctx) {
+		t.Error("Context with subscribed bus should be active")
+	}
+}
+
+func TestBus_SerializesHandlers(t *testing.T) {
+	bus := NewBus()
+
+	inHandler := 0
+	maxInHandler := 0
+	bus.Subscribe(func(Event) {
+		inHandler++
+		if inHandler > maxInHandler {
+			maxInHandler = inHandler
+		}
+		inHandler--
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bus.Publish(Event{Type: OutputLine})
+		}()
+	}
+	wg.Wait()
+
+	if maxInHandler != 1 {
+		t.Errorf("Handlers should never run concurrently, max concurrency = %d", maxInHandler)
+	}
+}
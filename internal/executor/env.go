@@ -0,0 +1,166 @@
+package executor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// keptEnv - переменные, которые наследуются и при очищенном окружении: без них не найти исполняемые файлы,
+// а на Windows не запускаются многие программы
+var keptEnv = []string{"PATH"}
+
+func init() {
+	if runtime.GOOS == "windows" {
+		keptEnv = append(keptEnv, "PATHEXT", "SYSTEMROOT", "COMSPEC", "TEMP", "TMP")
+	}
+}
+
+// commandEnv собирает окружение команды: унаследованные переменные, затем .env файлы по порядку, затем явные
+// переменные; более поздние значения перекрывают ранние. nil - окружение aifr наследуется без изменений
+func commandEnv(exe execution) ([]string, error) {
+	clean := exe.cleanEnv || len(exe.envAllow) > 0
+	if !clean && len(exe.envFiles) == 0 && len(exe.env) == 0 {
+		return nil, nil
+	}
+
+	env := os.Environ()
+	if clean {
+		env = filterEnv(env, append(append([]string{}, keptEnv...), exe.envAllow...))
+	}
+
+	for _, path := range exe.envFiles {
+		// Относительный путь .env файла отсчитывается от рабочей директории команды, как и ее аргументы
+		if !filepath.IsAbs(path) && exe.dir != "" {
+			path = filepath.Join(exe.dir, path)
+		}
+		values, err := readEnvFile(path)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, values...)
+	}
+
+	return append(env, exe.env...), nil
+}
+
+// envNames возвращает имена переменных из списка KEY=VALUE: значения могут быть секретами,
+// поэтому в результаты и отчеты попадают только имена
+func envNames(env []string) []string {
+	if len(env) == 0 {
+		return nil
+	}
+	names := make([]string, len(env))
+	for i, entry := range env {
+		names[i], _, _ = strings.Cut(entry, "=")
+	}
+	return names
+}
+
+// filterEnv оставляет переменные из списка allow; "NPM_*" разрешает все переменные с префиксом NPM_
+func filterEnv(env, allow []string) []string {
+	filtered := []string{}
+	for _, entry := range env {
+		name, _, _ := strings.Cut(entry, "=")
+		for _, pattern := range allow {
+			if matchEnvName(pattern, name) {
+				filtered = append(filtered, entry)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+func matchEnvName(pattern, name string) bool {
+	// На Windows имена переменных окружения не различают регистр
+	if runtime.GOOS == "windows" {
+		pattern, name = strings.ToUpper(pattern), strings.ToUpper(name)
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(name, prefix)
+	}
+	return pattern == name
+}
+
+// readEnvFile читает .env файл: строки KEY=VALUE, необязательный префикс "export", комментарии "#",
+// значения в одинарных кавычках как есть, в двойных - с экранированием \n, \t, \" и \\. Подстановка $VAR не выполняется
+func readEnvFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	defer file.Close()
+
+	env := []string{}
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		name = strings.TrimSpace(name)
+		if !ok || !isEnvAssignment(name+"=") {
+			return nil, fmt.Errorf("invalid env file %s:%d: expected KEY=VALUE", path, number)
+		}
+
+		value, err := parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid env file %s:%d: %w", path, number, err)
+		}
+		env = append(env, name+"="+value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read env file %s: %w", path, err)
+	}
+
+	return env, nil
+}
+
+// parseEnvValue снимает кавычки со значения; у значения без кавычек отрезается комментарий " #"
+func parseEnvValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	switch value[0] {
+	case '\'':
+		end := strings.IndexByte(value[1:], '\'')
+		if end < 0 {
+			return "", errors.New("unterminated quote")
+		}
+		return value[1 : end+1], nil
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(value); i++ {
+			switch c := value[i]; {
+			case c == '"':
+				return b.String(), nil
+			case c == '\\' && i+1 < len(value):
+				i++
+				switch value[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				default:
+					b.WriteByte(value[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", errors.New("unterminated quote")
+	}
+
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(value), nil
+}
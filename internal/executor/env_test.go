@@ -0,0 +1,82 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := `# база данных
+DB_HOST=localhost
+export DB_PORT=5432
+EMPTY=
+SPACED = value with spaces  # комментарий
+SINGLE='raw $HOME \n'
+DOUBLE="line\nnext \"quoted\""
+URL=http://host/#anchor
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readEnvFile(path)
+	if err != nil {
+		t.Fatalf("readEnvFile() error = %v", err)
+	}
+
+	want := []string{
+		"DB_HOST=localhost",
+		"DB_PORT=5432",
+		"EMPTY=",
+		"SPACED=value with spaces",
+		`SINGLE=raw $HOME \n`,
+		"DOUBLE=line\nnext \"quoted\"",
+		"URL=http://host/#anchor",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("readEnvFile() = %q, want %q", got, want)
+	}
+}
+
+func TestReadEnvFile_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"строка без =", "DB_HOST\n", ".env:1: expected KEY=VALUE"},
+		{"недопустимое имя", "# comment\n1DB=x\n", ".env:2: expected KEY=VALUE"},
+		{"незакрытая кавычка", `KEY="value`, ".env:1: unterminated quote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".env")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := readEnvFile(path); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("readEnvFile() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := readEnvFile(filepath.Join(t.TempDir(), "missing.env")); err == nil {
+		t.Error("Missing env file should be an error")
+	}
+}
+
+func TestFilterEnv(t *testing.T) {
+	env := []string{"PATH=/bin", "HOME=/root", "NPM_TOKEN=x", "NPM_CONFIG_CACHE=y", "SECRET=z"}
+
+	got := filterEnv(env, []string{"PATH", "NPM_*"})
+
+	want := []string{"PATH=/bin", "NPM_TOKEN=x", "NPM_CONFIG_CACHE=y"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("filterEnv() = %q, want %q", got, want)
+	}
+}
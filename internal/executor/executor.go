@@ -1,15 +1,18 @@
 package executor
 
 import (
-	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/CyberWalrus/ai-friendly-runner/internal/events"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/parser"
 	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
 	"github.com/mattn/go-shellwords"
 )
@@ -33,22 +36,19 @@ func putBuffer(buf *bytes.Buffer) {
 	bufferPool.Put(buf)
 }
 
-var directCommands = []string{"yarn", "npm", "pnpm", "npx", "node", "go", "python", "cross-env", "tsx"}
-
 func shouldRunDirect(command string) bool {
-	for _, cmd := range directCommands {
-		if strings.HasPrefix(command, cmd+" ") || command == cmd {
-			return true
-		}
-	}
-	return false
+	name, _, _ := strings.Cut(command, " ")
+	return parser.IsRunnerCommand(name)
 }
 
-func determineCommandToRun(command string) string {
+func determineCommandToRun(dir, command string) string {
 	if shouldRunDirect(command) {
 		return command
 	}
-	return command
+	if dir == "" {
+		dir = "."
+	}
+	return parser.ResolveCommand(dir, command)
 }
 
 // ExecCommand выполняет команду и возвращает результат
@@ -58,160 +58,383 @@ func ExecCommand(command string, flags types.Flags) types.CommandResult {
 
 // ExecCommandWithContext выполняет команду с поддержкой context
 func ExecCommandWithContext(ctx context.Context, command string, flags types.Flags) types.CommandResult {
-	startTime := time.Now()
-	fullCommand := determineCommandToRun(command)
+	return ExecSpecWithContext(ctx, types.CommandSpec{Command: command}, flags)
+}
+
+// execution содержит все параметры запуска одной команды
+type execution struct {
+	originalCommand string
+	fullCommand     string
+	useShell        bool
+	dir             string
+	env             []string
+	envFiles        []string
+	envAllow        []string
+	cleanEnv        bool
+	gracePeriod     time.Duration
+	startTime       time.Time
+}
+
+// ExecSpecWithContext выполняет команду с индивидуальными опциями и повторяет ее при ошибке согласно --retries
+func ExecSpecWithContext(ctx context.Context, spec types.CommandSpec, flags types.Flags) types.CommandResult {
+	result := execAttempt(ctx, spec, flags)
 
-	if flags.Stream {
-		return execCommandStreamWithContext(ctx, command, fullCommand, startTime)
+	retries := commandRetries(spec, flags)
+	if retries == 0 {
+		return result
 	}
 
-	return execCommandBufferedWithContext(ctx, command, fullCommand, startTime)
-}
+	first := result
+	attempts := []types.Attempt{attemptOf(result)}
 
-func execCommandStreamWithContext(ctx context.Context, originalCommand, fullCommand string, startTime time.Time) types.CommandResult {
-	parts, err := shellwords.Parse(fullCommand)
-	if err != nil {
-		return types.CommandResult{
-			Command:   originalCommand,
-			Duration:  time.Since(startTime),
-			IsSuccess: false,
-			Stderr:    fmt.Sprintf("Invalid command: %v", err),
+	for attempt := 2; attempt <= retries+1 && shouldRetry(ctx, result); attempt++ {
+		delay := retryDelay(flags.RetryBackoff, attempt)
+		failed := result
+		events.Publish(ctx, events.Event{Type: events.CommandRetrying, Attempt: attempt, Delay: delay, Result: &failed})
+
+		if !sleepContext(ctx, delay) {
+			break
 		}
+
+		result = execAttempt(ctx, spec, flags)
+		attempts = append(attempts, attemptOf(result))
 	}
 
-	if len(parts) == 0 {
-		return types.CommandResult{
-			Command:   originalCommand,
-			Duration:  time.Since(startTime),
-			IsSuccess: false,
-			Stderr:    "Empty command",
-		}
+	if len(attempts) > 1 {
+		result.Attempts = attempts
+		result.StartedAt = first.StartedAt
+		result.Duration = result.FinishedAt.Sub(first.StartedAt)
 	}
 
-	cmd := exec.CommandContext(ctx, parts[0], parts[1:]...)
+	return result
+}
 
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return types.CommandResult{
-			Command:   originalCommand,
-			Duration:  time.Since(startTime),
-			IsSuccess: false,
-			Stderr:    fmt.Sprintf("Failed to create stdout pipe: %v", err),
-		}
+// execAttempt выполняет одну попытку команды
+func execAttempt(ctx context.Context, spec types.CommandSpec, flags types.Flags) types.CommandResult {
+	exe := newExecution(spec, flags)
+
+	timeout := commandTimeout(spec, flags)
+	cmdCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		cmdCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return types.CommandResult{
-			Command:   originalCommand,
-			Duration:  time.Since(startTime),
-			IsSuccess: false,
-			Stderr:    fmt.Sprintf("Failed to create stderr pipe: %v", err),
-		}
+	// Построчное чтение нужно и для --stream, и для живых подписчиков шины событий
+	var result types.CommandResult
+	if flags.Stream || events.Active(ctx) {
+		result = execCommandStreamWithContext(cmdCtx, exe)
+	} else {
+		result = execCommandBufferedWithContext(cmdCtx, exe)
 	}
 
-	if err := cmd.Start(); err != nil {
-		return types.CommandResult{
-			Command:   originalCommand,
-			Duration:  time.Since(startTime),
-			IsSuccess: false,
-			Stderr:    fmt.Sprintf("Failed to start command: %v", err),
+	result.ResolvedCommand = exe.fullCommand
+	result.Shell = exe.useShell
+	result.Dir = spec.Dir
+	result.Env = envNames(spec.Env)
+	result.EnvFiles = spec.EnvFiles
+	result.EnvAllow = spec.EnvAllow
+	result.CleanEnv = spec.CleanEnv
+
+	if result.FailureKind == types.FailureTimeout {
+		if ctx.Err() != nil {
+			result.Reason = "global timeout expired while running"
+		} else {
+			result.Reason = fmt.Sprintf("command timeout %s expired", timeout)
 		}
 	}
 
-	stdoutBuf := getBuffer()
-	stderrBuf := getBuffer()
-	defer putBuffer(stdoutBuf)
-	defer putBuffer(stderrBuf)
+	return result
+}
 
-	prefix := originalCommand
-	if len(prefix) > 15 {
-		prefix = prefix[:15]
+// commandRetries возвращает число повторов: опция команды приоритетнее флага --retries
+func commandRetries(spec types.CommandSpec, flags types.Flags) int {
+	if spec.Retries != nil {
+		return *spec.Retries
 	}
-	prefix = fmt.Sprintf("[%-15s]: ", prefix)
+	return flags.Retries
+}
 
-	var wg sync.WaitGroup
-	wg.Add(2)
+// shouldRetry проверяет, имеет ли смысл повторять команду: повторяются только упавшие, убитые
+// сигналом или превысившие свой таймаут команды, и только пока запуск не отменен
+func shouldRetry(ctx context.Context, result types.CommandResult) bool {
+	if result.IsSuccess || ctx.Err() != nil {
+		return false
+	}
 
-	go func() {
-		defer wg.Done()
-		stdoutScanner := bufio.NewScanner(stdout)
-		for stdoutScanner.Scan() {
-			line := stdoutScanner.Text()
-			fmt.Println(prefix + line)
-			stdoutBuf.WriteString(line)
-			stdoutBuf.WriteByte('\n')
+	switch result.FailureKind {
+	case types.FailureExit, types.FailureSignal, types.FailureTimeout:
+		return true
+	}
+	return false
+}
+
+// retryDelay возвращает паузу перед попыткой attempt: backoff, затем вдвое больше с каждой попыткой
+func retryDelay(backoff time.Duration, attempt int) time.Duration {
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff << (attempt - 2)
+}
+
+// sleepContext ждет delay; возвращает false, если контекст отменен раньше
+func sleepContext(ctx context.Context, delay time.Duration) bool {
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func attemptOf(result types.CommandResult) types.Attempt {
+	return types.Attempt{
+		Duration:    result.Duration,
+		ExitCode:    result.ExitCode,
+		Signal:      result.Signal,
+		FailureKind: result.FailureKind,
+	}
+}
+
+// newExecution разрешает команду и собирает параметры ее запуска
+func newExecution(spec types.CommandSpec, flags types.Flags) execution {
+	fullCommand := determineCommandToRun(spec.Dir, spec.Command)
+
+	return execution{
+		originalCommand: spec.Command,
+		fullCommand:     fullCommand,
+		useShell:        shouldUseShell(spec, fullCommand, flags),
+		dir:             spec.Dir,
+		env:             spec.Env,
+		envFiles:        spec.EnvFiles,
+		envAllow:        spec.EnvAllow,
+		cleanEnv:        spec.CleanEnv,
+		gracePeriod:     flags.GracePeriod,
+		startTime:       time.Now(),
+	}
+}
+
+// commandTimeout возвращает таймаут команды: опция команды приоритетнее флага --command-timeout
+func commandTimeout(spec types.CommandSpec, flags types.Flags) time.Duration {
+	if spec.Timeout > 0 {
+		return spec.Timeout
+	}
+	return flags.CommandTimeout
+}
+
+// shouldUseShell решает, выполнять ли команду через shell: опция команды приоритетнее флага --shell и автоопределения
+func shouldUseShell(spec types.CommandSpec, fullCommand string, flags types.Flags) bool {
+	switch spec.Shell {
+	case types.ShellAlways:
+		return true
+	case types.ShellNever:
+		return false
+	}
+
+	return flags.Shell || needsShell(fullCommand)
+}
+
+// newCommand создает exec.Cmd напрямую через shellwords или через shell
+func newCommand(ctx context.Context, exe execution) (*exec.Cmd, error) {
+	if strings.TrimSpace(exe.fullCommand) == "" {
+		return nil, errors.New("Empty command")
+	}
+
+	var cmd *exec.Cmd
+	if exe.useShell {
+		shell, args := shellCommand(exe.fullCommand)
+		cmd = exec.CommandContext(ctx, shell, args...)
+	} else {
+		parts, err := shellwords.Parse(exe.fullCommand)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid command: %v", err)
 		}
-	}()
-
-	go func() {
-		defer wg.Done()
-		stderrScanner := bufio.NewScanner(stderr)
-		for stderrScanner.Scan() {
-			line := stderrScanner.Text()
-			fmt.Println(prefix + line)
-			stderrBuf.WriteString(line)
-			stderrBuf.WriteByte('\n')
+
+		if len(parts) == 0 {
+			return nil, errors.New("Empty command")
 		}
-	}()
 
-	wg.Wait()
-	err = cmd.Wait()
-	duration := time.Since(startTime)
+		cmd = exec.CommandContext(ctx, parts[0], parts[1:]...)
+	}
+
+	cmd.Dir = exe.dir
+	env, err := commandEnv(exe)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Env = env
+
+	return cmd, nil
+}
+
+// startProcess запускает команду в отдельной группе процессов и регистрирует её для KillAll
+func startProcess(cmd *exec.Cmd, stopper *processStopper) error {
+	stopper.attach(cmd)
 
-	stdoutStr := stdoutBuf.String()
-	stderrStr := stderrBuf.String()
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	trackProcess(cmd.Process.Pid)
+	return nil
+}
+
+// publishStarted публикует событие CommandStarted
+func publishStarted(ctx context.Context, cmd *exec.Cmd, exe execution) {
+	events.Publish(ctx, events.Event{
+		Type:            events.CommandStarted,
+		ResolvedCommand: exe.fullCommand,
+		Pid:             cmd.Process.Pid,
+	})
+}
+
+// waitProcess дожидается завершения команды и убирает оставшихся потомков, если команда была отменена
+func waitProcess(cmd *exec.Cmd, stopper *processStopper) error {
+	err := cmd.Wait()
+
+	stopper.finish(cmd.Process.Pid)
+	untrackProcess(cmd.Process.Pid)
+
+	return err
+}
+
+// startFailure формирует результат для команды, которую не удалось запустить
+func startFailure(exe execution, message string) types.CommandResult {
+	finishTime := time.Now()
 
 	return types.CommandResult{
-		Command:   originalCommand,
-		Duration:  duration,
-		IsSuccess: err == nil,
-		Stdout:    stdoutStr,
-		Stderr:    stderrStr,
+		Command:     exe.originalCommand,
+		Duration:    finishTime.Sub(exe.startTime),
+		IsSuccess:   false,
+		Stderr:      message + "\n",
+		ExitCode:    -1,
+		FailureKind: types.FailureStart,
+		StartedAt:   exe.startTime,
+		FinishedAt:  finishTime,
 	}
 }
 
-func execCommandBufferedWithContext(ctx context.Context, originalCommand, fullCommand string, startTime time.Time) types.CommandResult {
-	parts, err := shellwords.Parse(fullCommand)
-	if err != nil {
-		return types.CommandResult{
-			Command:   originalCommand,
-			Duration:  time.Since(startTime),
-			IsSuccess: false,
-			Stderr:    fmt.Sprintf("Invalid command: %v", err),
+// finishResult формирует результат завершившейся команды: код выхода, сигнал и причину ошибки
+func finishResult(ctx context.Context, cmd *exec.Cmd, waitErr error, exe execution) types.CommandResult {
+	finishTime := time.Now()
+
+	result := types.CommandResult{
+		Command:    exe.originalCommand,
+		Duration:   finishTime.Sub(exe.startTime),
+		IsSuccess:  waitErr == nil,
+		ExitCode:   -1,
+		StartedAt:  exe.startTime,
+		FinishedAt: finishTime,
+	}
+
+	if state := cmd.ProcessState; state != nil {
+		result.ExitCode = state.ExitCode()
+		if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			result.Signal = signalName(status.Signal())
 		}
 	}
 
-	if len(parts) == 0 {
-		return types.CommandResult{
-			Command:   originalCommand,
-			Duration:  time.Since(startTime),
-			IsSuccess: false,
-			Stderr:    "Empty command",
+	// Процесс завершился успешно, но вывод прочитан не полностью: потомки держали stdout/stderr дольше
+	// WaitDelay или чтение pipe завершилось ошибкой
+	var exitErr *exec.ExitError
+	if waitErr != nil && !errors.As(waitErr, &exitErr) && ctx.Err() == nil && result.ExitCode == 0 {
+		result.IsSuccess = true
+		if !errors.Is(waitErr, exec.ErrWaitDelay) {
+			result.OutputError = fmt.Sprintf("failed to read output: %v", waitErr)
 		}
+		return result
+	}
+
+	if waitErr == nil {
+		return result
+	}
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.FailureKind = types.FailureTimeout
+	case errors.Is(ctx.Err(), context.Canceled):
+		result.FailureKind = types.FailureCancelled
+	case result.Signal != "":
+		result.FailureKind = types.FailureSignal
+	case result.ExitCode > 0:
+		result.FailureKind = types.FailureExit
+	default:
+		result.FailureKind = types.FailureStart
 	}
 
-	cmd := exec.CommandContext(ctx, parts[0], parts[1:]...)
+	return result
+}
+
+func execCommandStreamWithContext(ctx context.Context, exe execution) types.CommandResult {
+	cmd, err := newCommand(ctx, exe)
+	if err != nil {
+		return startFailure(exe, err.Error())
+	}
+
+	stdoutBuf := getBuffer()
+	stderrBuf := getBuffer()
+	defer putBuffer(stdoutBuf)
+	defer putBuffer(stderrBuf)
+
+	recorder := &outputRecorder{}
+	stdout := newLineSplitter(ctx, events.StreamStdout, stdoutBuf, recorder)
+	stderr := newLineSplitter(ctx, events.StreamStderr, stderrBuf, recorder)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	stopper := newProcessStopper(exe.gracePeriod)
+	if err := startProcess(cmd, stopper); err != nil {
+		return startFailure(exe, fmt.Sprintf("Failed to start command: %v", err))
+	}
+	publishStarted(ctx, cmd, exe)
+
+	err = waitProcess(cmd, stopper)
+	stdout.close()
+	stderr.close()
+
+	result := finishResult(ctx, cmd, err, exe)
+	result.Stdout = stdoutBuf.String()
+	result.Stderr = stderrBuf.String()
+	result.Output = recorder.output()
+
+	return result
+}
+
+func execCommandBufferedWithContext(ctx context.Context, exe execution) types.CommandResult {
+	cmd, err := newCommand(ctx, exe)
+	if err != nil {
+		return startFailure(exe, err.Error())
+	}
 
 	stdout := getBuffer()
 	stderr := getBuffer()
 	defer putBuffer(stdout)
 	defer putBuffer(stderr)
 
-	cmd.Stdout = stdout
-	cmd.Stderr = stderr
+	recorder := &outputRecorder{}
+	cmd.Stdout = recordingWriter{buf: stdout, stream: events.StreamStdout, recorder: recorder}
+	cmd.Stderr = recordingWriter{buf: stderr, stream: events.StreamStderr, recorder: recorder}
+
+	stopper := newProcessStopper(exe.gracePeriod)
+	if err := startProcess(cmd, stopper); err != nil {
+		return startFailure(exe, fmt.Sprintf("Failed to start command: %v", err))
+	}
+	publishStarted(ctx, cmd, exe)
 
-	err = cmd.Run()
-	duration := time.Since(startTime)
+	err = waitProcess(cmd, stopper)
 
-	stdoutStr := stdout.String()
-	stderrStr := stderr.String()
+	result := finishResult(ctx, cmd, err, exe)
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+	result.Output = recorder.output()
 
-	return types.CommandResult{
-		Command:   originalCommand,
-		Duration:  duration,
-		IsSuccess: err == nil,
-		Stdout:    stdoutStr,
-		Stderr:    stderrStr,
-	}
+	return result
 }
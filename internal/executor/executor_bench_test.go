@@ -50,7 +50,7 @@ func BenchmarkCommandExecution(b *testing.B) {
 	b.Run("BufferedMode", func(b *testing.B) {
 		ctx := context.Background()
 		for i := 0; i < b.N; i++ {
-			_ = execCommandBufferedWithContext(ctx, "test", "echo test", time.Now())
+			_ = execCommandBufferedWithContext(ctx, execution{originalCommand: "test", fullCommand: "echo test", startTime: time.Now()})
 		}
 	})
 }
@@ -68,7 +68,7 @@ func BenchmarkDetermineCommand(b *testing.B) {
 	for _, tc := range testCases {
 		b.Run(tc.name, func(b *testing.B) {
 			for i := 0; i < b.N; i++ {
-				_ = determineCommandToRun(tc.command)
+				_ = determineCommandToRun("", tc.command)
 			}
 		})
 	}
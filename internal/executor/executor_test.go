@@ -0,0 +1,284 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+func TestExecCommandWithContext_FailureKinds(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		kind     types.FailureKind
+		exitCode int
+		signal   string
+	}{
+		{name: "успех", command: "echo ok", kind: types.FailureNone, exitCode: 0},
+		{name: "ненулевой код", command: "exit 2", kind: types.FailureExit, exitCode: 2},
+		{name: "бинарник не найден", command: "aifr-missing-binary", kind: types.FailureStart, exitCode: -1},
+		{name: "сигнал", command: "kill -9 $$", kind: types.FailureSignal, exitCode: -1, signal: "SIGKILL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, stream := range []bool{false, true} {
+				result := ExecCommandWithContext(context.Background(), tt.command, types.Flags{Stream: stream})
+
+				if result.FailureKind != tt.kind {
+					t.Errorf("stream=%v: FailureKind = %q, want %q", stream, result.FailureKind, tt.kind)
+				}
+				if result.ExitCode != tt.exitCode {
+					t.Errorf("stream=%v: ExitCode = %d, want %d", stream, result.ExitCode, tt.exitCode)
+				}
+				if result.Signal != tt.signal {
+					t.Errorf("stream=%v: Signal = %q, want %q", stream, result.Signal, tt.signal)
+				}
+				if result.StartedAt.IsZero() || result.FinishedAt.Before(result.StartedAt) {
+					t.Errorf("stream=%v: invalid timestamps %v - %v", stream, result.StartedAt, result.FinishedAt)
+				}
+			}
+		})
+	}
+}
+
+func TestExecCommandWithContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	result := ExecCommandWithContext(ctx, "sleep 5", types.Flags{})
+
+	if result.FailureKind != types.FailureCancelled {
+		t.Errorf("FailureKind = %q, want %q", result.FailureKind, types.FailureCancelled)
+	}
+}
+
+func TestExecSpecWithContext_CommandTimeout(t *testing.T) {
+	spec := types.CommandSpec{Command: "echo partial; sleep 5", Timeout: 100 * time.Millisecond}
+	flags := types.Flags{GracePeriod: 50 * time.Millisecond}
+
+	start := time.Now()
+	result := ExecSpecWithContext(context.Background(), spec, flags)
+
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("Timeout was not enforced: %v", time.Since(start))
+	}
+	if result.FailureKind != types.FailureTimeout {
+		t.Errorf("FailureKind = %q, want %q", result.FailureKind, types.FailureTimeout)
+	}
+	if result.Reason != "command timeout 100ms expired" {
+		t.Errorf("Reason = %q", result.Reason)
+	}
+	if result.Stdout != "partial\n" {
+		t.Errorf("Partial output should be preserved, got %q", result.Stdout)
+	}
+}
+
+func TestExecSpecWithContext_GracePeriodEscalation(t *testing.T) {
+	spec := types.CommandSpec{Command: "trap 'echo stopping' INT TERM; while true; do sleep 0.02; done", Timeout: 100 * time.Millisecond}
+	flags := types.Flags{CommandTimeout: time.Hour, GracePeriod: 150 * time.Millisecond}
+
+	start := time.Now()
+	result := ExecSpecWithContext(context.Background(), spec, flags)
+	duration := time.Since(start)
+
+	// SIGINT через 100ms, SIGTERM через 250ms, SIGKILL через 400ms
+	if duration < 350*time.Millisecond || duration > 2*time.Second {
+		t.Errorf("Expected SIGKILL after two grace periods, took %v", duration)
+	}
+	if result.FailureKind != types.FailureTimeout {
+		t.Errorf("FailureKind = %q, want %q", result.FailureKind, types.FailureTimeout)
+	}
+}
+
+func TestExecSpecWithContext_DirAndEnv(t *testing.T) {
+	dir := t.TempDir()
+	spec := types.CommandSpec{Command: "pwd && echo $AIFR_TEST_VALUE", Dir: dir, Env: []string{"AIFR_TEST_VALUE=from-config"}}
+
+	for _, stream := range []bool{false, true} {
+		result := ExecSpecWithContext(context.Background(), spec, types.Flags{Stream: stream})
+
+		if !result.IsSuccess {
+			t.Fatalf("stream=%v: command failed: %s", stream, result.Stderr)
+		}
+		want := dir + "\nfrom-config\n"
+		if result.Stdout != want {
+			t.Errorf("stream=%v: Stdout = %q, want %q", stream, result.Stdout, want)
+		}
+	}
+}
+
+func TestExecSpecWithContext_EnvFileAndCleanEnv(t *testing.T) {
+	t.Setenv("AIFR_TEST_ALLOWED", "allowed")
+	t.Setenv("AIFR_TEST_SECRET", "secret")
+
+	envFile := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(envFile, []byte("AIFR_TEST_FILE=from-file\nAIFR_TEST_OVERRIDE=from-file\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := types.CommandSpec{
+		Command:  `echo "$AIFR_TEST_ALLOWED,$AIFR_TEST_SECRET,$AIFR_TEST_FILE,$AIFR_TEST_OVERRIDE"`,
+		Shell:    types.ShellAlways,
+		Env:      []string{"AIFR_TEST_OVERRIDE=explicit"},
+		EnvFiles: []string{envFile},
+		EnvAllow: []string{"AIFR_TEST_ALLOW*"},
+	}
+
+	result := ExecSpecWithContext(context.Background(), spec, types.Flags{})
+
+	if !result.IsSuccess {
+		t.Fatalf("Command failed: %s", result.Stderr)
+	}
+	if result.Stdout != "allowed,,from-file,explicit\n" {
+		t.Errorf("Stdout = %q, want only allowed, file and explicit variables", result.Stdout)
+	}
+	if len(result.EnvFiles) != 1 || len(result.EnvAllow) != 1 {
+		t.Errorf("Result should describe the command environment, got %+v", result)
+	}
+	if len(result.Env) != 1 || result.Env[0] != "AIFR_TEST_OVERRIDE" {
+		t.Errorf("Result should keep only names of explicit variables, got %q", result.Env)
+	}
+}
+
+func TestExecSpecWithContext_EnvFileRelativeToDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("AIFR_TEST_FILE=from-cwd\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := types.CommandSpec{Command: "echo $AIFR_TEST_FILE", Shell: types.ShellAlways, Dir: dir, EnvFiles: []string{".env"}}
+	result := ExecSpecWithContext(context.Background(), spec, types.Flags{})
+
+	if !result.IsSuccess {
+		t.Fatalf("Env file should be resolved against the command cwd: %s", result.Reason)
+	}
+	if result.Stdout != "from-cwd\n" {
+		t.Errorf("Stdout = %q, want value from cwd/.env", result.Stdout)
+	}
+}
+
+func TestExecSpecWithContext_MissingEnvFile(t *testing.T) {
+	spec := types.CommandSpec{Command: "echo ok", EnvFiles: []string{filepath.Join(t.TempDir(), "missing.env")}}
+
+	result := ExecSpecWithContext(context.Background(), spec, types.Flags{})
+
+	if result.FailureKind != types.FailureStart || !strings.Contains(result.Stderr, "failed to read env file") {
+		t.Errorf("Expected a start failure, got %q: %s", result.FailureKind, result.Stderr)
+	}
+}
+
+func TestExecCommandWithContext_OutputStreams(t *testing.T) {
+	// Порядок между потоками не гарантирован: stdout и stderr - разные pipe. Гарантировано, что каждый
+	// байт попадает в Output один раз, с верным потоком и в порядке записи внутри потока
+	command := "echo 1; echo 2 >&2; echo 3; echo 4 >&2"
+
+	for _, stream := range []bool{false, true} {
+		result := ExecCommandWithContext(context.Background(), command, types.Flags{Shell: true, Stream: stream})
+
+		byStream := map[string]string{}
+		for _, chunk := range result.Output {
+			byStream[chunk.Stream] += chunk.Text
+		}
+		if byStream["stdout"] != "1\n3\n" || byStream["stderr"] != "2\n4\n" {
+			t.Errorf("stream=%v: Output by stream = %q, want stdout 1,3 and stderr 2,4", stream, byStream)
+		}
+		if result.Stdout != "1\n3\n" || result.Stderr != "2\n4\n" {
+			t.Errorf("stream=%v: Stdout = %q, Stderr = %q", stream, result.Stdout, result.Stderr)
+		}
+		if got := len(result.CombinedOutput()); got != len("1\n2\n3\n4\n") {
+			t.Errorf("stream=%v: CombinedOutput() = %q, want all four lines", stream, result.CombinedOutput())
+		}
+	}
+}
+
+func TestExecCommandWithContext_OutputOrder(t *testing.T) {
+	// Паузы между записями больше задержки чтения pipe, поэтому порядок между потоками здесь сохраняется;
+	// без пауз он приблизительный, см. TestExecCommandWithContext_OutputStreams
+	command := "echo a; sleep 0.05; echo b >&2; sleep 0.05; echo c"
+
+	for _, stream := range []bool{false, true} {
+		result := ExecCommandWithContext(context.Background(), command, types.Flags{Shell: true, Stream: stream})
+
+		if got := result.CombinedOutput(); got != "a\nb\nc\n" {
+			t.Errorf("stream=%v: CombinedOutput() = %q, want arrival order", stream, got)
+		}
+		streams := []string{}
+		for _, chunk := range result.Output {
+			streams = append(streams, chunk.Stream)
+			if chunk.Time.IsZero() {
+				t.Errorf("stream=%v: chunk %q has no time", stream, chunk.Text)
+			}
+		}
+		if strings.Join(streams, ",") != "stdout,stderr,stdout" {
+			t.Errorf("stream=%v: streams = %v", stream, streams)
+		}
+		if result.Stdout != "a\nc\n" || result.Stderr != "b\n" {
+			t.Errorf("stream=%v: separate streams should stay available, got %q and %q", stream, result.Stdout, result.Stderr)
+		}
+	}
+}
+
+func TestExecSpecWithContext_Retries(t *testing.T) {
+	dir := t.TempDir()
+	retries := 2
+	spec := types.CommandSpec{Command: "[ -f marker ] || { touch marker; exit 3; }", Dir: dir, Retries: &retries}
+
+	start := time.Now()
+	result := ExecSpecWithContext(context.Background(), spec, types.Flags{RetryBackoff: 50 * time.Millisecond})
+
+	if !result.IsSuccess || !result.PassedAfterRetry() {
+		t.Fatalf("Command should pass after retry, got %+v", result)
+	}
+	if len(result.Attempts) != 2 || result.Attempts[0].ExitCode != 3 || result.Attempts[1].ExitCode != 0 {
+		t.Errorf("Attempts = %+v, want exit 3 then 0", result.Attempts)
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Errorf("Backoff was not applied: %v", time.Since(start))
+	}
+	if result.Duration < 50*time.Millisecond {
+		t.Errorf("Duration should cover all attempts, got %v", result.Duration)
+	}
+}
+
+func TestExecSpecWithContext_RetriesExhausted(t *testing.T) {
+	result := ExecSpecWithContext(context.Background(), types.CommandSpec{Command: "exit 1"}, types.Flags{Retries: 2})
+
+	if result.IsSuccess || len(result.Attempts) != 3 {
+		t.Errorf("Expected 3 failed attempts, got success=%v attempts=%+v", result.IsSuccess, result.Attempts)
+	}
+}
+
+func TestExecSpecWithContext_NoRetryOnStartFailure(t *testing.T) {
+	result := ExecSpecWithContext(context.Background(), types.CommandSpec{Command: "aifr-missing-binary"}, types.Flags{Retries: 3})
+
+	if result.FailureKind != types.FailureStart || result.Attempts != nil {
+		t.Errorf("Start failures should not be retried, got kind %q attempts %+v", result.FailureKind, result.Attempts)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 2, want: time.Second},
+		{attempt: 3, want: 2 * time.Second},
+		{attempt: 4, want: 4 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := retryDelay(time.Second, tt.attempt); got != tt.want {
+			t.Errorf("retryDelay(1s, %d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+
+	if got := retryDelay(0, 3); got != 0 {
+		t.Errorf("retryDelay without backoff = %v, want 0", got)
+	}
+}
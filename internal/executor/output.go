@@ -0,0 +1,195 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/events"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+// partialLineDelay - через сколько незавершенная строка (приглашение ввода, прогресс-бар) публикуется частично
+const partialLineDelay = 100 * time.Millisecond
+
+// outputRecorder собирает вывод обоих потоков команды в порядке чтения; соседние куски одного потока
+// объединяются, время куска - время его первого байта. stdout и stderr - разные pipe, которые читаются
+// независимо, поэтому порядок внутри потока точный, а между потоками - приблизительный: записи, сделанные
+// почти одновременно, могут поменяться местами
+type outputRecorder struct {
+	mu     sync.Mutex
+	chunks []recordedChunk
+}
+
+type recordedChunk struct {
+	stream string
+	time   time.Time
+	text   bytes.Buffer
+}
+
+func (r *outputRecorder) record(stream string, text []byte) {
+	if len(text) == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if n := len(r.chunks); n == 0 || r.chunks[n-1].stream != stream {
+		r.chunks = append(r.chunks, recordedChunk{stream: stream, time: time.Now()})
+	}
+	r.chunks[len(r.chunks)-1].text.Write(text)
+}
+
+// output возвращает записанные куски для CommandResult.Output
+func (r *outputRecorder) output() []types.OutputChunk {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.chunks) == 0 {
+		return nil
+	}
+	output := make([]types.OutputChunk, len(r.chunks))
+	for i := range r.chunks {
+		output[i] = types.OutputChunk{Stream: r.chunks[i].stream, Time: r.chunks[i].time, Text: r.chunks[i].text.String()}
+	}
+	return output
+}
+
+// recordingWriter пишет поток в его буфер и в общую запись вывода
+type recordingWriter struct {
+	buf      *bytes.Buffer
+	stream   string
+	recorder *outputRecorder
+}
+
+func (w recordingWriter) Write(p []byte) (int, error) {
+	w.recorder.record(w.stream, p)
+	return w.buf.Write(p)
+}
+
+// lineSplitter сохраняет вывод в буфер результата как есть и делит его на строки для событий OutputLine.
+// В событиях "\r\n" считается переводом строки, а одиночный "\r" перезаписывает текущую строку, как
+// в терминале: от прогресс-бара остается последнее состояние. Сохраненный вывод не меняется, поэтому
+// результат совпадает с буферизованным запуском
+type lineSplitter struct {
+	mu       sync.Mutex
+	ctx      context.Context
+	stream   string
+	buf      *bytes.Buffer
+	recorder *outputRecorder
+	pending  []byte // текущая незавершенная строка
+	emitted  int    // сколько байт pending уже опубликовано частичными событиями
+	carriage bool   // кусок закончился на "\r": следующий байт решает, перевод это строки или перезапись
+	timer    *time.Timer
+}
+
+func newLineSplitter(ctx context.Context, stream string, buf *bytes.Buffer, recorder *outputRecorder) *lineSplitter {
+	return &lineSplitter{ctx: ctx, stream: stream, buf: buf, recorder: recorder}
+}
+
+// Write передает кусок вывода в write; lineSplitter подключается к cmd.Stdout и cmd.Stderr, поэтому
+// вывод читает сам exec.Cmd, а Wait соблюдает WaitDelay, даже если потомки держат pipe открытым
+func (s *lineSplitter) Write(p []byte) (int, error) {
+	s.write(p)
+	return len(p), nil
+}
+
+// write сохраняет и разбирает очередной кусок вывода
+func (s *lineSplitter) write(chunk []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buf.Write(chunk)
+	if s.recorder != nil {
+		s.recorder.record(s.stream, chunk)
+	}
+
+	if s.carriage && len(chunk) > 0 {
+		s.carriage = false
+		if chunk[0] == '\n' {
+			s.endLine()
+			chunk = chunk[1:]
+		} else {
+			s.overwrite()
+		}
+	}
+
+	for len(chunk) > 0 {
+		i := bytes.IndexAny(chunk, "\r\n")
+		if i < 0 {
+			s.pending = append(s.pending, chunk...)
+			break
+		}
+		s.pending = append(s.pending, chunk[:i]...)
+
+		switch {
+		case chunk[i] == '\n':
+			s.endLine()
+		case i+1 == len(chunk):
+			s.carriage = true
+		case chunk[i+1] == '\n':
+			s.endLine()
+			i++
+		default:
+			s.overwrite()
+		}
+		chunk = chunk[i+1:]
+	}
+
+	if len(s.pending) > s.emitted && s.timer == nil {
+		s.timer = time.AfterFunc(partialLineDelay, s.flushPartial)
+	}
+}
+
+// endLine публикует завершенную строку; если ее начало уже опубликовано частично, публикуется только остаток,
+// возможно пустой: он сообщает подписчикам, что строка закончилась
+func (s *lineSplitter) endLine() {
+	s.publish(s.pending[s.emitted:], false)
+	s.pending = s.pending[:0]
+	s.emitted = 0
+}
+
+// overwrite начинает публикуемую строку заново после одиночного "\r"
+func (s *lineSplitter) overwrite() {
+	s.pending = s.pending[:0]
+	s.emitted = 0
+}
+
+// flushPartial публикует незавершенную строку, которая не дописывается дольше partialLineDelay
+func (s *lineSplitter) flushPartial() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.timer = nil
+	if len(s.pending) > s.emitted {
+		s.publish(s.pending[s.emitted:], true)
+		s.emitted = len(s.pending)
+	}
+}
+
+// close публикует последнюю строку без перевода строки и останавливает таймер; вызывается после Wait
+func (s *lineSplitter) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+
+	if s.carriage {
+		s.carriage = false
+		s.endLine()
+	}
+	if len(s.pending) > 0 {
+		s.publish(s.pending[s.emitted:], false)
+		s.pending = s.pending[:0]
+		s.emitted = 0
+	}
+}
+
+func (s *lineSplitter) publish(line []byte, partial bool) {
+	events.Publish(s.ctx, events.Event{Type: events.OutputLine, Stream: s.stream, Line: string(line), Partial: partial})
+}
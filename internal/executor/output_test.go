@@ -0,0 +1,155 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/events"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+// collectLines подключает шину и собирает события OutputLine
+func collectLines(ctx context.Context) (context.Context, func() []events.Event) {
+	var mu sync.Mutex
+	collected := []events.Event{}
+
+	bus := events.NewBus()
+	bus.Subscribe(func(event events.Event) {
+		if event.Type != events.OutputLine {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		collected = append(collected, event)
+	})
+
+	return events.WithBus(ctx, bus), func() []events.Event {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]events.Event{}, collected...)
+	}
+}
+
+func lineTexts(collected []events.Event) []string {
+	lines := make([]string, len(collected))
+	for i, event := range collected {
+		lines[i] = event.Line
+		if event.Partial {
+			lines[i] += "…"
+		}
+	}
+	return lines
+}
+
+func TestLineSplitter_Lines(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   []string
+		buf    string
+	}{
+		{"строки", []string{"a\nb\n"}, []string{"a", "b"}, "a\nb\n"},
+		{"без перевода строки в конце", []string{"a\nb"}, []string{"a", "b"}, "a\nb"},
+		{"строка из нескольких кусков", []string{"hel", "lo\n"}, []string{"hello"}, "hello\n"},
+		{"пустые строки", []string{"\n\n"}, []string{"", ""}, "\n\n"},
+		{"CRLF", []string{"a\r\nb\r", "\n"}, []string{"a", "b"}, "a\r\nb\r\n"},
+		{"прогресс-бар", []string{"10%\r50%\r", "100%\ndone\n"}, []string{"100%", "done"}, "10%\r50%\r100%\ndone\n"},
+		{"\\r в конце вывода", []string{"progress\r"}, []string{"progress"}, "progress\r"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, lines := collectLines(context.Background())
+			var buf bytes.Buffer
+
+			splitter := newLineSplitter(ctx, events.StreamStdout, &buf, nil)
+			for _, chunk := range tt.chunks {
+				splitter.Write([]byte(chunk))
+			}
+			splitter.close()
+
+			if got := lineTexts(lines()); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("lines = %q, want %q", got, tt.want)
+			}
+			if buf.String() != tt.buf {
+				t.Errorf("buffer = %q, want %q", buf.String(), tt.buf)
+			}
+		})
+	}
+}
+
+func TestLineSplitter_PartialLine(t *testing.T) {
+	ctx, lines := collectLines(context.Background())
+	var buf bytes.Buffer
+
+	splitter := newLineSplitter(ctx, events.StreamStdout, &buf, nil)
+	splitter.Write([]byte("Password: "))
+	time.Sleep(3 * partialLineDelay)
+	splitter.Write([]byte("ok\nnext\n"))
+	splitter.close()
+
+	want := []string{"Password: …", "ok", "next"}
+	if got := lineTexts(lines()); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+	if buf.String() != "Password: ok\nnext\n" {
+		t.Errorf("buffer should keep the line whole, got %q", buf.String())
+	}
+}
+
+func TestFinishResult_OutputError(t *testing.T) {
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	result := finishResult(context.Background(), cmd, errors.New("read |0: input/output error"), execution{startTime: time.Now()})
+
+	if !result.IsSuccess || !strings.Contains(result.OutputError, "input/output error") {
+		t.Errorf("Read error after a successful exit should be reported as output error, got %+v", result)
+	}
+}
+
+func TestExecCommandWithContext_LongLine(t *testing.T) {
+	ctx, lines := collectLines(context.Background())
+
+	// 1 MiB в одной строке: bufio.Scanner с лимитом 64 KiB на этом останавливался
+	result := ExecCommandWithContext(ctx, "head -c 1048576 /dev/zero | tr '\\0' x; echo; echo done", types.Flags{Shell: true})
+
+	if !result.IsSuccess || result.OutputError != "" {
+		t.Fatalf("Command should pass, got %+v", result.FailureKind)
+	}
+	if len(result.Stdout) != 1048576+len("\ndone\n") {
+		t.Errorf("Expected the whole output, got %d bytes", len(result.Stdout))
+	}
+
+	collected := lines()
+	complete := 0
+	for _, event := range collected {
+		if !event.Partial {
+			complete++
+		}
+	}
+	if complete != 2 || collected[len(collected)-1].Line != "done" {
+		t.Errorf("Expected the long line and \"done\", got %d events", len(collected))
+	}
+}
+
+func TestExecCommandWithContext_CarriageReturnKeptInResult(t *testing.T) {
+	command := `printf '10%%\r50%%\r100%%\ndone\n'`
+	buffered := ExecCommandWithContext(context.Background(), command, types.Flags{})
+	streamed := ExecCommandWithContext(context.Background(), command, types.Flags{Stream: true})
+
+	if buffered.Stdout != "10%\r50%\r100%\ndone\n" {
+		t.Fatalf("Buffered stdout = %q", buffered.Stdout)
+	}
+	if streamed.Stdout != buffered.Stdout || streamed.CombinedOutput() != buffered.CombinedOutput() {
+		t.Errorf("Streamed output = %q, want the same bytes as buffered %q", streamed.CombinedOutput(), buffered.CombinedOutput())
+	}
+}
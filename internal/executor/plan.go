@@ -0,0 +1,86 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/parser"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+// PlanSpec описывает, как команда будет запущена: разрешенная команда, исполняемый файл, argv,
+// рабочая директория и окружение. Сама команда не выполняется
+func PlanSpec(spec types.CommandSpec, flags types.Flags) types.CommandPlan {
+	exe := newExecution(spec, flags)
+
+	plan := types.CommandPlan{
+		Name:            spec.Name,
+		Command:         spec.Command,
+		ResolvedCommand: exe.fullCommand,
+		Resolution:      describeResolution(spec.Dir, spec.Command),
+		Shell:           exe.useShell,
+		Dir:             planDir(spec.Dir),
+		Env:             envNames(spec.Env),
+		EnvFiles:        spec.EnvFiles,
+		EnvAllow:        spec.EnvAllow,
+		CleanEnv:        spec.CleanEnv,
+		Timeout:         commandTimeout(spec, flags),
+		Needs:           spec.Needs,
+	}
+
+	cmd, err := newCommand(context.Background(), exe)
+	if err != nil {
+		plan.Error = err.Error()
+		return plan
+	}
+
+	plan.Args = cmd.Args
+	if cmd.Err != nil {
+		plan.Error = cmd.Err.Error()
+		return plan
+	}
+	plan.Executable = cmd.Path
+
+	return plan
+}
+
+// describeResolution объясняет, почему команда была или не была превращена в вызов npm скрипта
+func describeResolution(dir, command string) string {
+	if shouldRunDirect(command) {
+		return "runner command, executed as is"
+	}
+
+	if dir == "" {
+		dir = "."
+	}
+
+	if !parser.IsNPMScript(dir, command) {
+		return "not a package.json script, executed as is"
+	}
+
+	pkg, _ := parser.ParsePackageJSON(dir)
+	manager, lockfile := parser.DetectPackageManagerSource(dir)
+
+	source := "no lockfile found, npm by default"
+	if lockfile != "" {
+		source = "detected from " + lockfile
+	}
+
+	return fmt.Sprintf("package.json script from %s; %s %s", filepath.Join(pkg.Dir, "package.json"), manager, source)
+}
+
+// planDir возвращает абсолютную рабочую директорию команды
+func planDir(dir string) string {
+	if dir == "" {
+		cwd, _ := os.Getwd()
+		return cwd
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	return absDir
+}
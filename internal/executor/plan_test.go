@@ -0,0 +1,93 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/parser"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+func TestPlanSpec(t *testing.T) {
+	parser.ClearCache()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"scripts": {"lint": "eslint ."}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pnpm-lock.yaml"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		spec       types.CommandSpec
+		resolved   string
+		args       []string
+		resolution string
+	}{
+		{
+			name:       "npm скрипт",
+			spec:       types.CommandSpec{Command: "lint --fix", Dir: dir},
+			resolved:   "pnpm run lint --fix",
+			args:       []string{"pnpm", "run", "lint", "--fix"},
+			resolution: "pnpm detected from " + filepath.Join(dir, "pnpm-lock.yaml"),
+		},
+		{
+			name:       "прямая команда",
+			spec:       types.CommandSpec{Command: `echo "a b" c`, Dir: dir},
+			resolved:   `echo "a b" c`,
+			args:       []string{"echo", "a b", "c"},
+			resolution: "not a package.json script",
+		},
+		{
+			name:       "shell",
+			spec:       types.CommandSpec{Command: "echo a | wc -l", Dir: dir},
+			resolved:   "echo a | wc -l",
+			args:       []string{"-c", "echo a | wc -l"},
+			resolution: "not a package.json script",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := PlanSpec(tt.spec, types.Flags{CommandTimeout: time.Minute})
+
+			if plan.ResolvedCommand != tt.resolved {
+				t.Errorf("ResolvedCommand = %q, want %q", plan.ResolvedCommand, tt.resolved)
+			}
+			// Для shell первый аргумент - путь к shell, он зависит от окружения
+			args := plan.Args
+			if plan.Shell {
+				args = args[1:]
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("Args = %q, want %q", plan.Args, tt.args)
+			}
+			if !strings.Contains(plan.Resolution, tt.resolution) {
+				t.Errorf("Resolution = %q, want containing %q", plan.Resolution, tt.resolution)
+			}
+			if plan.Dir != dir {
+				t.Errorf("Dir = %q, want %q", plan.Dir, dir)
+			}
+			if plan.Timeout != time.Minute {
+				t.Errorf("Timeout = %v, want 1m", plan.Timeout)
+			}
+		})
+	}
+}
+
+func TestPlanSpec_MissingExecutable(t *testing.T) {
+	plan := PlanSpec(types.CommandSpec{Command: "aifr-missing-binary --flag"}, types.Flags{})
+
+	if plan.Executable != "" || plan.Error == "" {
+		t.Errorf("Missing binary should produce an error, got executable %q error %q", plan.Executable, plan.Error)
+	}
+	if !reflect.DeepEqual(plan.Args, []string{"aifr-missing-binary", "--flag"}) {
+		t.Errorf("Args = %q", plan.Args)
+	}
+}
@@ -0,0 +1,99 @@
+package executor
+
+import (
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// stopSignals - последовательность сигналов, которыми останавливается группа процессов команды
+var stopSignals = []syscall.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGKILL}
+
+var (
+	activeMu sync.Mutex
+	active   = map[int]struct{}{}
+)
+
+// trackProcess запоминает группу процессов запущенной команды для KillAll
+func trackProcess(pid int) {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+
+	active[pid] = struct{}{}
+}
+
+// untrackProcess удаляет группу процессов из списка активных
+func untrackProcess(pid int) {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+
+	delete(active, pid)
+}
+
+// KillAll немедленно убивает группы процессов всех выполняющихся команд, минуя grace period
+func KillAll() {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+
+	for pid := range active {
+		_ = signalGroup(pid, syscall.SIGKILL)
+	}
+}
+
+// processStopper останавливает группу процессов при отмене context, повышая сигнал каждые gracePeriod
+type processStopper struct {
+	gracePeriod time.Duration
+	exited      chan struct{}
+	once        sync.Once
+	stopping    chan struct{}
+}
+
+func newProcessStopper(gracePeriod time.Duration) *processStopper {
+	return &processStopper{
+		gracePeriod: gracePeriod,
+		exited:      make(chan struct{}),
+		stopping:    make(chan struct{}),
+	}
+}
+
+// attach настраивает остановку группы процессов при отмене context команды
+func (s *processStopper) attach(cmd *exec.Cmd) {
+	configureProcess(cmd)
+
+	cmd.Cancel = func() error {
+		close(s.stopping)
+
+		if s.gracePeriod <= 0 {
+			return signalGroup(cmd.Process.Pid, syscall.SIGKILL)
+		}
+
+		go s.escalate(cmd.Process.Pid)
+		return signalGroup(cmd.Process.Pid, stopSignals[0])
+	}
+
+	// Страховка: если потомки держат pipe после всех сигналов, Wait закроет их сам
+	cmd.WaitDelay = time.Duration(len(stopSignals))*s.gracePeriod + time.Second
+}
+
+func (s *processStopper) escalate(pid int) {
+	for _, sig := range stopSignals[1:] {
+		select {
+		case <-time.After(s.gracePeriod):
+			_ = signalGroup(pid, sig)
+		case <-s.exited:
+			return
+		}
+	}
+}
+
+// finish вызывается после завершения команды; при отмене добивает оставшихся потомков
+func (s *processStopper) finish(pid int) {
+	s.once.Do(func() { close(s.exited) })
+
+	select {
+	case <-s.stopping:
+		_ = signalGroup(pid, syscall.SIGKILL)
+	default:
+	}
+}
@@ -0,0 +1,110 @@
+//go:build !windows
+
+package executor
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+func TestExecCommandWithContext_KillsProcessTree(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	flags := types.Flags{GracePeriod: 50 * time.Millisecond}
+	result := ExecCommandWithContext(ctx, "sh -c 'sleep 30 & echo $!; wait'", flags)
+
+	if result.FailureKind != types.FailureTimeout {
+		t.Fatalf("FailureKind = %q, want %q", result.FailureKind, types.FailureTimeout)
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(result.Stdout))
+	if err != nil {
+		t.Fatalf("Unexpected stdout %q", result.Stdout)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for isProcessAlive(pid) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Grandchild process %d is still alive after cancellation", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// isProcessAlive проверяет, что процесс существует и не является зомби
+func isProcessAlive(pid int) bool {
+	if syscall.Kill(pid, 0) != nil {
+		return false
+	}
+
+	output, err := exec.Command("ps", "-o", "stat=", "-p", strconv.Itoa(pid)).Output()
+	state := strings.TrimSpace(string(output))
+
+	return err == nil && state != "" && !strings.HasPrefix(state, "Z")
+}
+
+func TestKillAll(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	flags := types.Flags{GracePeriod: time.Hour}
+
+	done := make(chan types.CommandResult)
+	go func() {
+		done <- ExecCommandWithContext(ctx, "trap '' INT TERM; sleep 30", flags)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	KillAll()
+
+	select {
+	case result := <-done:
+		if result.IsSuccess {
+			t.Error("Killed command should fail")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("KillAll did not skip the grace period")
+	}
+}
+
+func TestExecCommandWithContext_StreamDoesNotWaitForDetachedChildren(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		timeout time.Duration
+		kind    types.FailureKind
+	}{
+		// setsid уводит внука из группы процессов: сигналы до него не доходят, но он держит pipe
+		{name: "таймаут", command: "sh -c 'setsid sleep 4 & sleep 10'", timeout: 300 * time.Millisecond, kind: types.FailureTimeout},
+		{name: "успешный выход", command: "sh -c 'sleep 4 & echo hi'", kind: types.FailureNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := types.Flags{Stream: true, GracePeriod: 50 * time.Millisecond, CommandTimeout: tt.timeout}
+
+			start := time.Now()
+			result := ExecCommandWithContext(context.Background(), tt.command, flags)
+			elapsed := time.Since(start)
+
+			if result.FailureKind != tt.kind {
+				t.Errorf("FailureKind = %q, want %q", result.FailureKind, tt.kind)
+			}
+			// WaitDelay = 3 grace periods + 1s; без него aifr ждал бы внука 4 секунды
+			if elapsed > 2500*time.Millisecond {
+				t.Errorf("Stream mode waited for the detached child: took %v", elapsed)
+			}
+			if tt.kind == types.FailureNone && result.Stdout != "hi\n" {
+				t.Errorf("Stdout = %q, want output written before exit", result.Stdout)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package executor
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// configureProcess запускает команду в новой группе процессов
+func configureProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// signalGroup завершает дерево процессов; на Windows нет POSIX сигналов, поэтому дерево убивается сразу
+func signalGroup(pid int, _ syscall.Signal) error {
+	return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(pid)).Run()
+}
@@ -0,0 +1,93 @@
+package executor
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// shellBuiltins содержит встроенные команды shell, которые нельзя запустить как бинарник
+var shellBuiltins = map[string]bool{
+	".": true, "alias": true, "case": true, "cd": true, "eval": true, "exec": true, "exit": true,
+	"export": true, "for": true, "if": true, "return": true, "set": true, "source": true,
+	"ulimit": true, "umask": true, "unset": true, "until": true, "while": true, "{": true, "(": true,
+}
+
+// needsShell проверяет, содержит ли команда метасимволы shell, встроенные команды или присваивание переменных
+func needsShell(command string) bool {
+	trimmed := strings.TrimSpace(command)
+	if trimmed == "" {
+		return false
+	}
+
+	firstWord := trimmed
+	if index := strings.IndexAny(trimmed, " \t"); index >= 0 {
+		firstWord = trimmed[:index]
+	}
+
+	if shellBuiltins[firstWord] || isEnvAssignment(firstWord) {
+		return true
+	}
+
+	return hasUnquotedMeta(trimmed)
+}
+
+func hasUnquotedMeta(command string) bool {
+	var quote rune
+	escaped := false
+
+	for _, r := range command {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else if quote == '"' && (r == '$' || r == '`') {
+				return true
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case strings.ContainsRune("|&;<>()$`*?~\n", r):
+			return true
+		}
+	}
+
+	return false
+}
+
+func isEnvAssignment(word string) bool {
+	name, _, ok := strings.Cut(word, "=")
+	if !ok || name == "" {
+		return false
+	}
+
+	for i, r := range name {
+		isLetter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !isLetter && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// shellCommand возвращает shell и аргументы для выполнения строки команды
+func shellCommand(command string) (string, []string) {
+	if runtime.GOOS == "windows" {
+		return "cmd", []string{"/C", command}
+	}
+
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "sh"
+	}
+	if _, err := exec.LookPath(shell); err != nil {
+		shell = "sh"
+	}
+
+	return shell, []string{"-c", command}
+}
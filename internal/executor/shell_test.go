@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+func TestNeedsShell(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{command: "echo hello", want: false},
+		{command: "go test ./...", want: false},
+		{command: "echo 'a | b'", want: false},
+		{command: "exit 1", want: true},
+		{command: "cd web && yarn build", want: true},
+		{command: "go test ./... | tee log", want: true},
+		{command: "echo done > out.txt", want: true},
+		{command: "FOO=1 make", want: true},
+		{command: "echo \"$HOME\"", want: true},
+		{command: "ls *.go", want: true},
+		{command: "npm run test -- --grep=a", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if got := needsShell(tt.command); got != tt.want {
+				t.Errorf("needsShell(%q) = %v, want %v", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecSpecWithContext_Shell(t *testing.T) {
+	flags := types.Flags{Output: "none"}
+
+	result := ExecSpecWithContext(context.Background(), types.CommandSpec{Command: "echo first && echo second | tr a-z A-Z"}, flags)
+	if !result.IsSuccess {
+		t.Fatalf("Compound command failed: %s", result.Stderr)
+	}
+
+	if !strings.Contains(result.Stdout, "first") || !strings.Contains(result.Stdout, "SECOND") {
+		t.Errorf("Unexpected stdout: %q", result.Stdout)
+	}
+
+	result = ExecSpecWithContext(context.Background(), types.CommandSpec{Command: "exit 3"}, flags)
+	if result.IsSuccess {
+		t.Error("exit 3 should fail")
+	}
+
+	result = ExecSpecWithContext(context.Background(), types.CommandSpec{Command: "echo $HOME", Shell: types.ShellNever}, flags)
+	if !result.IsSuccess || strings.TrimSpace(result.Stdout) != "$HOME" {
+		t.Errorf("ShellNever should pass metacharacters as arguments, got %q", result.Stdout)
+	}
+}
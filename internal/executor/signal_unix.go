@@ -0,0 +1,17 @@
+//go:build !windows
+
+package executor
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// signalName возвращает имя сигнала в виде "SIGKILL"
+func signalName(sig syscall.Signal) string {
+	if name := unix.SignalName(sig); name != "" {
+		return name
+	}
+	return sig.String()
+}
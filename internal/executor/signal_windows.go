@@ -0,0 +1,10 @@
+//go:build windows
+
+package executor
+
+import "syscall"
+
+// signalName возвращает имя сигнала
+func signalName(sig syscall.Signal) string {
+	return sig.String()
+}
@@ -0,0 +1,345 @@
+package logs
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/events"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+// IndexFile - имя файла с описанием запуска внутри директории запуска
+const IndexFile = "index.json"
+
+// runDirLayout - формат имени директории запуска; имена сортируются в хронологическом порядке
+const runDirLayout = "20060102-150405"
+
+// runDirPattern совпадает только с директориями, созданными aifr, чтобы ротация не трогала чужие файлы
+var runDirPattern = regexp.MustCompile(`^\d{8}-\d{6}(-\d+)?$`)
+
+// Writer пишет вывод команд запуска в файлы: stdout, stderr и общий лог с временем каждой строки.
+// Подписывается на шину событий как обработчик; вызовы обработчика сериализованы шиной
+type Writer struct {
+	dir   string
+	names []string
+	files map[int]*commandFiles
+	errs  []error
+}
+
+// commandFiles - открытые файлы логов одной команды
+type commandFiles struct {
+	paths    types.LogFiles
+	stdout   *os.File
+	stderr   *os.File
+	combined *os.File
+	stdoutW  *bufio.Writer
+	stderrW  *bufio.Writer
+	logW     *bufio.Writer
+	closed   bool
+	partial  map[string]bool // последняя строка потока была частичной
+}
+
+// Open создает директорию нового запуска в root и удаляет самые старые запуски, оставляя keep последних
+// вместе с новым (0 - хранить все). names задает базовые имена файлов команд по их индексу
+func Open(root string, keep int, names []string) (*Writer, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	if keep > 0 {
+		if err := rotate(root, keep-1); err != nil {
+			return nil, err
+		}
+	}
+
+	base := filepath.Join(root, time.Now().Format(runDirLayout))
+	dir := base
+	for n := 2; ; n++ {
+		err := os.Mkdir(dir, 0o755)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create log directory: %w", err)
+		}
+		dir = fmt.Sprintf("%s-%d", base, n)
+	}
+
+	return &Writer{dir: dir, names: names, files: map[int]*commandFiles{}}, nil
+}
+
+// rotate удаляет самые старые директории запусков, пока их не останется keep
+func rotate(root string, keep int) error {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return fmt.Errorf("failed to read log directory: %w", err)
+	}
+
+	runs := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() && runDirPattern.MatchString(entry.Name()) {
+			runs = append(runs, entry.Name())
+		}
+	}
+	sort.Strings(runs)
+
+	for len(runs) > keep {
+		if err := os.RemoveAll(filepath.Join(root, runs[0])); err != nil {
+			return fmt.Errorf("failed to remove old logs: %w", err)
+		}
+		runs = runs[1:]
+	}
+
+	return nil
+}
+
+// FileBase возвращает базовое имя файлов лога команды: порядковый номер и имя команды без спецсимволов
+func FileBase(index int, spec types.CommandSpec) string {
+	slug := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '-'
+	}, spec.Ref())
+
+	for strings.Contains(slug, "--") {
+		slug = strings.ReplaceAll(slug, "--", "-")
+	}
+	slug = strings.Trim(slug, "-")
+	if len(slug) > 60 {
+		slug = strings.TrimRight(slug[:60], "-")
+	}
+	if slug == "" {
+		slug = "command"
+	}
+
+	return fmt.Sprintf("%d-%s", index+1, slug)
+}
+
+// Names возвращает базовые имена файлов для списка команд
+func Names(specs []types.CommandSpec) []string {
+	names := make([]string, len(specs))
+	for i, spec := range specs {
+		names[i] = FileBase(i, spec)
+	}
+	return names
+}
+
+// SaveFull сохраняет полный вывод команды в dir/<FileBase>.log и возвращает путь к файлу
+func SaveFull(dir string, index int, spec types.CommandSpec, output string) (string, error) {
+	path := filepath.Join(dir, FileBase(index, spec)+".log")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create log directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(output), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write log: %w", err)
+	}
+	return path, nil
+}
+
+// Dir возвращает директорию текущего запуска
+func (w *Writer) Dir() string {
+	return w.dir
+}
+
+// Handle записывает события команды в ее файлы логов
+func (w *Writer) Handle(event events.Event) {
+	switch event.Type {
+	case events.CommandStarted:
+		files := w.open(event.Index)
+		if files == nil {
+			return
+		}
+		fmt.Fprintf(files.logW, "# %s started: %s\n", stamp(event.Time), event.ResolvedCommand)
+	case events.OutputLine:
+		files := w.files[event.Index]
+		if files == nil || files.closed {
+			return
+		}
+		target := files.stdoutW
+		if event.Stream == events.StreamStderr {
+			target = files.stderrW
+		}
+		// Частичная строка дописывается следующим событием, поэтому перевод строки не ставится
+		target.WriteString(event.Line)
+		if !event.Partial {
+			target.WriteByte('\n')
+		}
+		// Пустое завершение частичной строки в общем логе не нужно: ее текст уже записан
+		if event.Partial || event.Line != "" || !files.partial[event.Stream] {
+			fmt.Fprintf(files.logW, "%s %s | %s\n", stamp(event.Time), event.Stream, event.Line)
+		}
+		files.partial[event.Stream] = event.Partial
+	case events.CommandRetrying:
+		if files := w.files[event.Index]; files != nil && !files.closed {
+			fmt.Fprintf(files.logW, "# %s %s, retrying (attempt %d) in %s\n", stamp(event.Time), status(event.Result), event.Attempt, event.Delay)
+		}
+	case events.CommandFinished:
+		if files := w.files[event.Index]; files != nil && !files.closed {
+			fmt.Fprintf(files.logW, "# %s finished: %s in %dms\n", stamp(event.Time), status(event.Result), event.Result.Duration.Milliseconds())
+			w.close(files)
+		}
+	}
+}
+
+// open открывает файлы команды при первом запуске; при повторной попытке файлы дописываются
+func (w *Writer) open(index int) *commandFiles {
+	if files, ok := w.files[index]; ok {
+		return files
+	}
+	if index < 0 || index >= len(w.names) {
+		return nil
+	}
+
+	base := filepath.Join(w.dir, w.names[index])
+	files := &commandFiles{partial: map[string]bool{}, paths: types.LogFiles{
+		Stdout:   base + ".stdout.log",
+		Stderr:   base + ".stderr.log",
+		Combined: base + ".log",
+	}}
+
+	var err error
+	if files.stdout, err = os.Create(files.paths.Stdout); err == nil {
+		if files.stderr, err = os.Create(files.paths.Stderr); err == nil {
+			files.combined, err = os.Create(files.paths.Combined)
+		}
+	}
+	if err != nil {
+		w.errs = append(w.errs, fmt.Errorf("failed to create log file: %w", err))
+		for _, file := range []*os.File{files.stdout, files.stderr} {
+			if file != nil {
+				file.Close()
+			}
+		}
+		return nil
+	}
+
+	files.stdoutW = bufio.NewWriter(files.stdout)
+	files.stderrW = bufio.NewWriter(files.stderr)
+	files.logW = bufio.NewWriter(files.combined)
+	w.files[index] = files
+
+	return files
+}
+
+// close сбрасывает буферы и закрывает файлы команды; повторный вызов ничего не делает
+func (w *Writer) close(files *commandFiles) {
+	if files.closed {
+		return
+	}
+	files.closed = true
+
+	for _, pair := range []struct {
+		buffer *bufio.Writer
+		file   *os.File
+	}{{files.stdoutW, files.stdout}, {files.stderrW, files.stderr}, {files.logW, files.combined}} {
+		if err := pair.buffer.Flush(); err != nil {
+			w.errs = append(w.errs, fmt.Errorf("failed to write log: %w", err))
+		}
+		if err := pair.file.Close(); err != nil {
+			w.errs = append(w.errs, fmt.Errorf("failed to write log: %w", err))
+		}
+	}
+}
+
+// indexEntry - описание команды в index.json
+type indexEntry struct {
+	Index      int    `json:"index"`
+	Name       string `json:"name"`
+	Command    string `json:"command"`
+	Status     string `json:"status"`
+	ExitCode   int    `json:"exit_code"`
+	DurationMs int64  `json:"duration_ms"`
+	Stdout     string `json:"stdout,omitempty"`
+	Stderr     string `json:"stderr,omitempty"`
+	Log        string `json:"log,omitempty"`
+}
+
+// runIndex - содержимое index.json
+type runIndex struct {
+	Version    string       `json:"version"`
+	Cwd        string       `json:"cwd"`
+	StartedAt  time.Time    `json:"started_at"`
+	FinishedAt time.Time    `json:"finished_at"`
+	Commands   []indexEntry `json:"commands"`
+}
+
+// Finish закрывает оставшиеся файлы, записывает пути логов в результаты и создает index.json.
+// Пути в index.json относительны директории запуска
+func (w *Writer) Finish(results []types.CommandResult, info types.RunInfo) ([]types.CommandResult, error) {
+	for _, files := range w.files {
+		w.close(files)
+	}
+
+	index := runIndex{
+		Version:    info.Version,
+		Cwd:        info.Cwd,
+		StartedAt:  info.StartedAt,
+		FinishedAt: info.FinishedAt,
+		Commands:   make([]indexEntry, len(results)),
+	}
+
+	for i := range results {
+		entry := indexEntry{
+			Index:      i,
+			Command:    results[i].Command,
+			Status:     status(&results[i]),
+			ExitCode:   results[i].ExitCode,
+			DurationMs: results[i].Duration.Milliseconds(),
+		}
+		if i < len(w.names) {
+			entry.Name = w.names[i]
+		}
+
+		if files, ok := w.files[i]; ok {
+			paths := files.paths
+			results[i].Logs = &paths
+			entry.Stdout = filepath.Base(paths.Stdout)
+			entry.Stderr = filepath.Base(paths.Stderr)
+			entry.Log = filepath.Base(paths.Combined)
+		}
+
+		index.Commands[i] = entry
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(w.dir, IndexFile), append(data, '\n'), 0o644)
+	}
+	if err != nil {
+		w.errs = append(w.errs, fmt.Errorf("failed to write log index: %w", err))
+	}
+
+	return results, errors.Join(w.errs...)
+}
+
+// stamp форматирует время строки лога
+func stamp(t time.Time) string {
+	return t.Format("15:04:05.000")
+}
+
+// status возвращает краткий итог команды для логов
+func status(result *types.CommandResult) string {
+	switch {
+	case result == nil:
+		return "unknown"
+	case result.IsSuccess:
+		return "passed"
+	case result.FailureKind == types.FailureSkipped:
+		return "skipped"
+	case result.Signal != "":
+		return "killed by " + result.Signal
+	case result.FailureKind != types.FailureNone:
+		return fmt.Sprintf("failed (%s, exit code %d)", result.FailureKind, result.ExitCode)
+	default:
+		return fmt.Sprintf("failed (exit code %d)", result.ExitCode)
+	}
+}
@@ -0,0 +1,152 @@
+package logs
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/events"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+func TestWriter(t *testing.T) {
+	root := t.TempDir()
+	specs := []types.CommandSpec{{Name: "unit", Command: "go test ./..."}, {Command: "echo skipped"}}
+
+	writer, err := Open(root, 0, Names(specs))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	now := time.Now()
+	failed := &types.CommandResult{ExitCode: 1, Duration: 120 * time.Millisecond}
+	for _, event := range []events.Event{
+		{Type: events.CommandStarted, Index: 0, Time: now, ResolvedCommand: "go test ./..."},
+		{Type: events.OutputLine, Index: 0, Time: now, Stream: events.StreamStdout, Line: "=== RUN TestA"},
+		{Type: events.OutputLine, Index: 0, Time: now, Stream: events.StreamStderr, Line: "panic: boom"},
+		{Type: events.CommandRetrying, Index: 0, Time: now, Attempt: 2, Result: failed},
+		{Type: events.CommandStarted, Index: 0, Time: now, ResolvedCommand: "go test ./..."},
+		{Type: events.OutputLine, Index: 0, Time: now, Stream: events.StreamStdout, Line: "ok"},
+		{Type: events.CommandFinished, Index: 0, Time: now, Result: &types.CommandResult{IsSuccess: true}},
+	} {
+		writer.Handle(event)
+	}
+
+	results := []types.CommandResult{
+		{Command: "go test ./...", IsSuccess: true},
+		{Command: "echo skipped", FailureKind: types.FailureSkipped, ExitCode: -1},
+	}
+	results, err = writer.Finish(results, types.RunInfo{Version: "test"})
+	if err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+
+	if results[0].Logs == nil || results[0].Logs.Stdout != filepath.Join(writer.Dir(), "1-unit.stdout.log") {
+		t.Fatalf("Expected log paths for the started command, got %+v", results[0].Logs)
+	}
+	if results[1].Logs != nil {
+		t.Errorf("Command that never started should have no logs, got %+v", results[1].Logs)
+	}
+
+	read := func(path string) string {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", path, err)
+		}
+		return string(data)
+	}
+
+	if got := read(results[0].Logs.Stdout); got != "=== RUN TestA\nok\n" {
+		t.Errorf("stdout log = %q", got)
+	}
+	if got := read(results[0].Logs.Stderr); got != "panic: boom\n" {
+		t.Errorf("stderr log = %q", got)
+	}
+
+	combined := read(results[0].Logs.Combined)
+	stamp := now.Format("15:04:05.000")
+	for _, want := range []string{
+		"# " + stamp + " started: go test ./...\n",
+		stamp + " stdout | === RUN TestA\n" + stamp + " stderr | panic: boom\n",
+		"failed (exit code 1), retrying (attempt 2)",
+		"finished: passed",
+	} {
+		if !strings.Contains(combined, want) {
+			t.Errorf("Combined log should contain %q, got:\n%s", want, combined)
+		}
+	}
+
+	var index runIndex
+	if err := json.Unmarshal([]byte(read(filepath.Join(writer.Dir(), IndexFile))), &index); err != nil {
+		t.Fatalf("Invalid index.json: %v", err)
+	}
+	if len(index.Commands) != 2 || index.Commands[0].Log != "1-unit.log" || index.Commands[1].Status != "skipped" {
+		t.Errorf("Unexpected index: %+v", index.Commands)
+	}
+}
+
+func TestOpen_Rotation(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"20240101-100000", "20240102-100000", "20240103-100000", "20240103-100000-2", "notes"} {
+		if err := os.Mkdir(filepath.Join(root, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writer, err := Open(root, 3, nil)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	want := []string{"20240103-100000", "20240103-100000-2", filepath.Base(writer.Dir()), "notes"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("Directories after rotation = %v, want %v", names, want)
+	}
+}
+
+func TestOpen_SameSecond(t *testing.T) {
+	root := t.TempDir()
+
+	first, err := Open(root, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Open(root, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.Dir() == second.Dir() {
+		t.Errorf("Runs should get separate directories, both got %s", first.Dir())
+	}
+}
+
+func TestFileBase(t *testing.T) {
+	tests := []struct {
+		index int
+		spec  types.CommandSpec
+		want  string
+	}{
+		{0, types.CommandSpec{Name: "test:e2e", Command: "yarn e2e"}, "1-test-e2e"},
+		{2, types.CommandSpec{Command: "go test ./... | tee out.log"}, "3-go-test-tee-out-log"},
+		{1, types.CommandSpec{Command: "???"}, "2-command"},
+	}
+
+	for _, tt := range tests {
+		if got := FileBase(tt.index, tt.spec); got != tt.want {
+			t.Errorf("FileBase(%d, %+v) = %q, want %q", tt.index, tt.spec, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-shellwords"
+)
+
+// PackageJSON содержит используемые поля package.json
+type PackageJSON struct {
+	Name    string            `json:"name"`
+	Scripts map[string]string `json:"scripts"`
+	Dir     string            `json:"-"`
+}
+
+// ErrPackageJSONNotFound возвращается, если package.json не найден ни в одной из родительских директорий
+var ErrPackageJSONNotFound = errors.New("package.json not found")
+
+type cacheEntry struct {
+	pkg *PackageJSON
+	err error
+}
+
+var (
+	cacheMu sync.RWMutex
+	cache   = map[string]cacheEntry{}
+)
+
+// lockfiles сопоставляет lock-файлы с пакетными менеджерами в порядке приоритета
+var lockfiles = []struct {
+	name    string
+	manager string
+}{
+	{"bun.lockb", "bun"},
+	{"bun.lock", "bun"},
+	{"pnpm-lock.yaml", "pnpm"},
+	{"yarn.lock", "yarn"},
+	{"package-lock.json", "npm"},
+}
+
+// runnerCommands содержит команды, которые никогда не интерпретируются как npm скрипты
+var runnerCommands = []string{"yarn", "npm", "pnpm", "bun", "npx", "pnpx", "bunx", "node", "go", "python", "cross-env", "tsx"}
+
+// FindPackageJSON ищет ближайший package.json, поднимаясь от dir к корню файловой системы
+func FindPackageJSON(dir string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		candidate := filepath.Join(absDir, "package.json")
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+
+		parent := filepath.Dir(absDir)
+		if parent == absDir {
+			return "", ErrPackageJSONNotFound
+		}
+		absDir = parent
+	}
+}
+
+// ParsePackageJSON читает ближайший к dir package.json и кеширует результат
+func ParsePackageJSON(dir string) (*PackageJSON, error) {
+	path, err := FindPackageJSON(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheMu.RLock()
+	entry, ok := cache[path]
+	cacheMu.RUnlock()
+	if ok {
+		return entry.pkg, entry.err
+	}
+
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	if entry, ok := cache[path]; ok {
+		return entry.pkg, entry.err
+	}
+
+	pkg, err := readPackageJSON(path)
+	cache[path] = cacheEntry{pkg: pkg, err: err}
+
+	return pkg, err
+}
+
+func readPackageJSON(path string) (*PackageJSON, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &PackageJSON{}
+	if err := json.Unmarshal(data, pkg); err != nil {
+		return nil, err
+	}
+	pkg.Dir = filepath.Dir(path)
+
+	return pkg, nil
+}
+
+// ClearCache сбрасывает кеш разобранных package.json
+func ClearCache() {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	cache = map[string]cacheEntry{}
+}
+
+// IsNPMScript проверяет, является ли первое слово команды скриптом из ближайшего к dir package.json
+func IsNPMScript(dir, command string) bool {
+	name, _ := splitScript(command)
+	if name == "" || IsRunnerCommand(name) {
+		return false
+	}
+
+	pkg, err := ParsePackageJSON(dir)
+	if err != nil {
+		return false
+	}
+
+	_, ok := pkg.Scripts[name]
+	return ok
+}
+
+// DetectPackageManager определяет пакетный менеджер по lock-файлу, поднимаясь от dir к корню (по умолчанию npm)
+func DetectPackageManager(dir string) string {
+	manager, _ := DetectPackageManagerSource(dir)
+	return manager
+}
+
+// DetectPackageManagerSource определяет пакетный менеджер и возвращает путь к lock-файлу, по которому он выбран;
+// пустой путь означает npm по умолчанию
+func DetectPackageManagerSource(dir string) (string, string) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "npm", ""
+	}
+
+	for {
+		for _, lockfile := range lockfiles {
+			candidate := filepath.Join(absDir, lockfile.name)
+			if _, err := os.Stat(candidate); err == nil {
+				return lockfile.manager, candidate
+			}
+		}
+
+		parent := filepath.Dir(absDir)
+		if parent == absDir {
+			return "npm", ""
+		}
+		absDir = parent
+	}
+}
+
+// ResolveCommand превращает имя npm скрипта в вызов "<pm> run <script>", остальные команды возвращает без изменений
+func ResolveCommand(dir, command string) string {
+	if !IsNPMScript(dir, command) {
+		return command
+	}
+
+	name, rest := splitScript(command)
+	manager := DetectPackageManager(dir)
+
+	resolved := manager + " run " + name
+	if rest == "" {
+		return resolved
+	}
+
+	if manager == "npm" {
+		return resolved + " -- " + rest
+	}
+
+	return resolved + " " + rest
+}
+
+// execCommands сопоставляет пакетные менеджеры с командами запуска бинарников из node_modules/.bin
+var execCommands = map[string]string{
+	"npm":  "npx",
+	"yarn": "yarn",
+	"pnpm": "pnpm exec",
+	"bun":  "bunx",
+}
+
+// BinaryCommand возвращает команду запуска бинарника из node_modules/.bin через пакетный менеджер проекта
+func BinaryCommand(dir, name string) string {
+	return execCommands[DetectPackageManager(dir)] + " " + name
+}
+
+func splitScript(command string) (string, string) {
+	trimmed := strings.TrimSpace(command)
+	if trimmed == "" {
+		return "", ""
+	}
+
+	parts, err := shellwords.Parse(trimmed)
+	if err != nil || len(parts) == 0 {
+		return "", ""
+	}
+
+	name := parts[0]
+	index := strings.Index(trimmed, name)
+	if index != 0 {
+		// Первое слово было в кавычках, такая команда не является именем скрипта
+		return "", ""
+	}
+
+	return name, strings.TrimSpace(trimmed[len(name):])
+}
+
+// IsRunnerCommand проверяет, является ли name пакетным менеджером или рантаймом, который запускается как есть
+func IsRunnerCommand(name string) bool {
+	for _, cmd := range runnerCommands {
+		if name == cmd {
+			return true
+		}
+	}
+	return false
+}
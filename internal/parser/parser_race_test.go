@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestParsePackageJSON_Concurrent(t *testing.T) {
+	dir := newProject(t, "yarn.lock")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			pkg, err := ParsePackageJSON(dir)
+			if err != nil {
+				t.Errorf("ParsePackageJSON() error = %v", err)
+				return
+			}
+
+			if _, ok := pkg.Scripts["lint"]; !ok {
+				t.Error("Concurrent parse should see lint script")
+			}
+
+			if got := ResolveCommand(dir, "lint"); got != "yarn run lint" {
+				t.Errorf("ResolveCommand() = %q, want %q", got, "yarn run lint")
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestClearCache_Concurrent(t *testing.T) {
+	dir := newProject(t, "")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			IsNPMScript(dir, "test")
+		}()
+
+		go func() {
+			defer wg.Done()
+			ClearCache()
+		}()
+	}
+
+	wg.Wait()
+}
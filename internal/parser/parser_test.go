@@ -0,0 +1,188 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll(%q) failed: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile(%q) failed: %v", path, err)
+	}
+}
+
+func newProject(t *testing.T, lockfile string) string {
+	t.Helper()
+	ClearCache()
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "package.json"), `{
+		"name": "fixture",
+		"scripts": {"lint": "eslint .", "test": "jest", "lint:ts": "tsc --noEmit"}
+	}`)
+	if lockfile != "" {
+		writeFile(t, filepath.Join(dir, lockfile), "")
+	}
+
+	return dir
+}
+
+func TestParsePackageJSON(t *testing.T) {
+	dir := newProject(t, "")
+
+	pkg, err := ParsePackageJSON(dir)
+	if err != nil {
+		t.Fatalf("ParsePackageJSON() error = %v", err)
+	}
+
+	if pkg.Name != "fixture" {
+		t.Errorf("Name = %q, want %q", pkg.Name, "fixture")
+	}
+
+	if pkg.Scripts["lint"] != "eslint ." {
+		t.Errorf("Scripts[lint] = %q, want %q", pkg.Scripts["lint"], "eslint .")
+	}
+}
+
+func TestParsePackageJSON_WalksUp(t *testing.T) {
+	dir := newProject(t, "")
+	nested := filepath.Join(dir, "src", "deep")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := ParsePackageJSON(nested)
+	if err != nil {
+		t.Fatalf("ParsePackageJSON() error = %v", err)
+	}
+
+	if _, ok := pkg.Scripts["test"]; !ok {
+		t.Error("Nested directory should resolve parent package.json")
+	}
+}
+
+func TestParsePackageJSON_Invalid(t *testing.T) {
+	ClearCache()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "package.json"), "{invalid")
+
+	if _, err := ParsePackageJSON(dir); err == nil {
+		t.Error("Expected error for invalid package.json")
+	}
+}
+
+func TestIsNPMScript(t *testing.T) {
+	dir := newProject(t, "")
+
+	tests := []struct {
+		name    string
+		command string
+		want    bool
+	}{
+		{name: "скрипт", command: "lint", want: true},
+		{name: "скрипт с двоеточием", command: "lint:ts", want: true},
+		{name: "скрипт с аргументами", command: "test --watch", want: true},
+		{name: "неизвестный скрипт", command: "build", want: false},
+		{name: "прямая команда", command: "echo lint", want: false},
+		{name: "пакетный менеджер", command: "yarn", want: false},
+		{name: "пустая команда", command: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsNPMScript(dir, tt.command); got != tt.want {
+				t.Errorf("IsNPMScript(%q) = %v, want %v", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectPackageManager(t *testing.T) {
+	tests := []struct {
+		lockfile string
+		want     string
+	}{
+		{lockfile: "yarn.lock", want: "yarn"},
+		{lockfile: "pnpm-lock.yaml", want: "pnpm"},
+		{lockfile: "package-lock.json", want: "npm"},
+		{lockfile: "bun.lockb", want: "bun"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lockfile, func(t *testing.T) {
+			dir := newProject(t, tt.lockfile)
+
+			if got := DetectPackageManager(dir); got != tt.want {
+				t.Errorf("DetectPackageManager() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		lockfile string
+		command  string
+		want     string
+	}{
+		{name: "npm скрипт", lockfile: "package-lock.json", command: "lint", want: "npm run lint"},
+		{name: "npm скрипт с аргументами", lockfile: "package-lock.json", command: "test --watch", want: "npm run test -- --watch"},
+		{name: "yarn скрипт", lockfile: "yarn.lock", command: "lint:ts", want: "yarn run lint:ts"},
+		{name: "yarn скрипт с аргументами", lockfile: "yarn.lock", command: "test --watch", want: "yarn run test --watch"},
+		{name: "pnpm скрипт", lockfile: "pnpm-lock.yaml", command: "lint", want: "pnpm run lint"},
+		{name: "bun скрипт", lockfile: "bun.lockb", command: "test", want: "bun run test"},
+		{name: "без lock-файла", lockfile: "", command: "lint", want: "npm run lint"},
+		{name: "прямая команда", lockfile: "yarn.lock", command: "go vet ./...", want: "go vet ./..."},
+		{name: "неизвестная команда", lockfile: "yarn.lock", command: "echo hello", want: "echo hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newProject(t, tt.lockfile)
+
+			if got := ResolveCommand(dir, tt.command); got != tt.want {
+				t.Errorf("ResolveCommand(%q) = %q, want %q", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBinaryCommand(t *testing.T) {
+	tests := []struct {
+		lockfile string
+		want     string
+	}{
+		{lockfile: "", want: "npx eslint"},
+		{lockfile: "yarn.lock", want: "yarn eslint"},
+		{lockfile: "pnpm-lock.yaml", want: "pnpm exec eslint"},
+		{lockfile: "bun.lock", want: "bunx eslint"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			dir := newProject(t, tt.lockfile)
+
+			if got := BinaryCommand(dir, "eslint"); got != tt.want {
+				t.Errorf("BinaryCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRunnerCommand(t *testing.T) {
+	for _, name := range []string{"yarn", "npm", "pnpm", "bun", "npx", "pnpx", "bunx", "node", "go"} {
+		if !IsRunnerCommand(name) {
+			t.Errorf("IsRunnerCommand(%q) = false, want true", name)
+		}
+	}
+	if IsRunnerCommand("lint") {
+		t.Error("IsRunnerCommand(\"lint\") = true, want false")
+	}
+}
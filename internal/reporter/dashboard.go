@@ -0,0 +1,306 @@
+package reporter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/events"
+	"github.com/mattn/go-isatty"
+)
+
+// dashboardInterval - период перерисовки таблицы и смены кадра спиннера
+const dashboardInterval = 100 * time.Millisecond
+
+// defaultTerminalWidth используется, если ширину терминала узнать не удалось
+const defaultTerminalWidth = 80
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// controlPattern совпадает с ANSI последовательностями и управляющими символами, которые ломают строку таблицы
+var controlPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|[\x00-\x08\x0b-\x1f\x7f]`)
+
+// Состояния команды в таблице
+const (
+	stateQueued   = "queued"
+	stateRunning  = "running"
+	stateRetrying = "retrying"
+	statePassed   = "passed"
+	stateFailed   = "failed"
+	stateSkipped  = "skipped"
+)
+
+// IsInteractive проверяет, что вывод идет в терминал человеку: не в файл или pipe, не в CI и не в dumb терминал
+func IsInteractive(file *os.File) bool {
+	if os.Getenv("CI") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isatty.IsTerminal(file.Fd()) || isatty.IsCygwinTerminal(file.Fd())
+}
+
+// Dashboard - живая таблица команд для интерактивного терминала: спиннер, состояние, время выполнения
+// и последняя строка вывода каждой команды. Подписывается на шину событий как обработчик
+type Dashboard struct {
+	mu     sync.Mutex
+	out    io.Writer
+	width  int
+	height int
+	rows   []dashboardRow
+	frame  int
+	drawn  int // сколько строк занимает последняя отрисовка
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// dashboardRow - строка таблицы для одной команды
+type dashboardRow struct {
+	name     string
+	state    string
+	started  time.Time
+	duration time.Duration
+	attempt  int
+	line     string
+}
+
+// NewDashboard создает таблицу для команд names; width и height - размер терминала, 0 - неизвестен
+func NewDashboard(out io.Writer, names []string, width, height int) *Dashboard {
+	if width <= 0 {
+		width = defaultTerminalWidth
+	}
+
+	rows := make([]dashboardRow, len(names))
+	for i, name := range names {
+		rows[i] = dashboardRow{name: cleanCommandName(name), state: stateQueued}
+	}
+
+	return &Dashboard{out: out, width: width, height: height, rows: rows}
+}
+
+// NewTerminalDashboard создает таблицу, которая рисуется в stdout по размеру терминала
+func NewTerminalDashboard(names []string) *Dashboard {
+	width, height := terminalSize(os.Stdout)
+	return NewDashboard(os.Stdout, names, width, height)
+}
+
+// Handle обновляет строку команды по событию; перерисовка происходит по таймеру
+func (d *Dashboard) Handle(event events.Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if event.Index < 0 || event.Index >= len(d.rows) {
+		return
+	}
+	row := &d.rows[event.Index]
+
+	switch event.Type {
+	case events.CommandStarted:
+		if row.started.IsZero() {
+			row.started = event.Time
+		}
+		row.state = stateRunning
+	case events.OutputLine:
+		if line := strings.TrimSpace(controlPattern.ReplaceAllString(event.Line, "")); line != "" {
+			row.line = line
+		}
+	case events.CommandRetrying:
+		row.state = stateRetrying
+		row.attempt = event.Attempt
+	case events.CommandFinished:
+		row.state = stateFailed
+		if result := event.Result; result != nil {
+			row.duration = result.Duration
+			switch {
+			case result.IsSuccess:
+				row.state = statePassed
+			case resultStatus(*result) == stateSkipped:
+				row.state = stateSkipped
+			}
+		}
+	}
+}
+
+// Start скрывает курсор и начинает перерисовывать таблицу
+func (d *Dashboard) Start() {
+	d.stop = make(chan struct{})
+	d.done = make(chan struct{})
+	fmt.Fprint(d.out, "\x1b[?25l")
+
+	go func() {
+		defer close(d.done)
+
+		ticker := time.NewTicker(dashboardInterval)
+		defer ticker.Stop()
+
+		d.draw()
+		for {
+			select {
+			case <-d.stop:
+				return
+			case <-ticker.C:
+				d.draw()
+			}
+		}
+	}()
+}
+
+// Stop останавливает перерисовку, стирает таблицу и возвращает курсор: дальше выводится обычный отчет
+func (d *Dashboard) Stop() {
+	if d.stop == nil {
+		return
+	}
+	close(d.stop)
+	<-d.done
+	d.stop = nil
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.drawn > 0 {
+		fmt.Fprintf(d.out, "\x1b[%dA\x1b[J", d.drawn)
+		d.drawn = 0
+	}
+	fmt.Fprint(d.out, "\x1b[?25h")
+}
+
+// draw перерисовывает таблицу поверх предыдущей отрисовки
+func (d *Dashboard) draw() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	lines := d.render(time.Now())
+	d.frame++
+
+	var b strings.Builder
+	if d.drawn > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", d.drawn)
+	}
+	for _, line := range lines {
+		b.WriteString("\r\x1b[2K")
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	if len(lines) < d.drawn {
+		b.WriteString("\x1b[J")
+	}
+	fmt.Fprint(d.out, b.String())
+	d.drawn = len(lines)
+}
+
+// render возвращает строки таблицы. Если команды не помещаются по высоте терминала,
+// прошедшие команды скрываются первыми, а остаток сворачивается в строку "… N more"
+func (d *Dashboard) render(now time.Time) []string {
+	nameWidth := 0
+	for _, row := range d.rows {
+		nameWidth = max(nameWidth, len([]rune(row.name)))
+	}
+	nameWidth = min(nameWidth, max(d.width/3, 10))
+
+	rows := d.rows
+	limit := len(rows)
+	if d.height > 0 {
+		limit = max(d.height-2, 1)
+	}
+	passed := 0
+	if len(rows) > limit {
+		visible := []dashboardRow{}
+		for _, row := range rows {
+			if row.state == statePassed {
+				passed++
+				continue
+			}
+			visible = append(visible, row)
+		}
+		rows = visible
+	}
+
+	lines := []string{}
+	hidden := 0
+	for i, row := range rows {
+		if len(rows) > limit && i >= limit-1 {
+			hidden = len(rows) - i
+			break
+		}
+		lines = append(lines, d.renderRow(row, nameWidth, now))
+	}
+
+	more := []string{}
+	if hidden > 0 {
+		more = append(more, fmt.Sprintf("%d more", hidden))
+	}
+	if passed > 0 {
+		more = append(more, fmt.Sprintf("%d passed", passed))
+	}
+	if len(more) > 0 {
+		lines = append(lines, dim("… "+strings.Join(more, ", ")))
+	}
+
+	return lines
+}
+
+// renderRow форматирует строку команды: символ состояния, имя, состояние, время и последнюю строку вывода
+func (d *Dashboard) renderRow(row dashboardRow, nameWidth int, now time.Time) string {
+	symbol, state := dim("·"), row.state
+	elapsed := row.duration
+	switch row.state {
+	case stateRunning, stateRetrying:
+		symbol = yellow(spinnerFrames[d.frame%len(spinnerFrames)])
+		elapsed = now.Sub(row.started)
+		if row.attempt > 1 {
+			state = fmt.Sprintf("try %d", row.attempt)
+		}
+	case statePassed:
+		symbol = green("✓")
+	case stateFailed:
+		symbol, state = red("✗"), red(fmt.Sprintf("%-8s", state))
+	case stateSkipped:
+		symbol = dim("-")
+	}
+
+	timeStr := ""
+	if row.state != stateQueued && row.state != stateSkipped {
+		timeStr = formatElapsed(elapsed)
+	}
+
+	// Символ, состояние, время и отступы занимают 21 колонку; последняя колонка остается пустой,
+	// чтобы терминал не переносил строку и перерисовка не съезжала
+	prefix := fmt.Sprintf("%s %s %-8s %7s", symbol, padRunes(row.name, nameWidth), state, timeStr)
+	if room := d.width - nameWidth - 22; room > 0 && row.line != "" {
+		prefix += "  " + dim(truncateRunes(row.line, room))
+	}
+
+	return prefix
+}
+
+// formatElapsed форматирует время выполнения для таблицы: 850ms, 12.3s, 2m05s
+func formatElapsed(elapsed time.Duration) string {
+	switch {
+	case elapsed < time.Second:
+		return fmt.Sprintf("%dms", elapsed.Milliseconds())
+	case elapsed < time.Minute:
+		return fmt.Sprintf("%.1fs", elapsed.Seconds())
+	default:
+		return fmt.Sprintf("%dm%02ds", int(elapsed.Minutes()), int(elapsed.Seconds())%60)
+	}
+}
+
+// padRunes дополняет или обрезает текст до width символов
+func padRunes(text string, width int) string {
+	text = truncateRunes(text, width)
+	return text + strings.Repeat(" ", width-len([]rune(text)))
+}
+
+// truncateRunes обрезает текст до width символов, заменяя последний символ на "…"
+func truncateRunes(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	if width <= 1 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,124 @@
+package reporter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/events"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+func TestDashboard_Render(t *testing.T) {
+	started := time.Date(2025, 11, 4, 10, 0, 0, 0, time.UTC)
+	dashboard := NewDashboard(&bytes.Buffer{}, []string{"lint", "yarn test", "build", "e2e"}, 60, 0)
+
+	for _, event := range []events.Event{
+		{Type: events.CommandStarted, Index: 0, Time: started},
+		{Type: events.CommandFinished, Index: 0, Result: &types.CommandResult{IsSuccess: true, Duration: 850 * time.Millisecond}},
+		{Type: events.CommandStarted, Index: 1, Time: started},
+		{Type: events.OutputLine, Index: 1, Line: "\x1b[32mPASS\x1b[0m src/a.test.ts"},
+		{Type: events.OutputLine, Index: 1, Line: "   "},
+		{Type: events.CommandStarted, Index: 2, Time: started},
+		{Type: events.CommandFinished, Index: 2, Result: &types.CommandResult{ExitCode: 1, FailureKind: types.FailureExit, Duration: 2 * time.Minute}},
+	} {
+		dashboard.Handle(event)
+	}
+
+	got := dashboard.render(started.Add(12300 * time.Millisecond))
+
+	want := []string{
+		"✓ lint  passed     850ms",
+		"⠋ test  running    12.3s  PASS src/a.test.ts",
+		"✗ build failed     2m00s",
+		"· e2e   queued          ",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("render() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDashboard_RenderRetryAndWidth(t *testing.T) {
+	started := time.Date(2025, 11, 4, 10, 0, 0, 0, time.UTC)
+	dashboard := NewDashboard(&bytes.Buffer{}, []string{"test"}, 40, 0)
+
+	dashboard.Handle(events.Event{Type: events.CommandStarted, Index: 0, Time: started})
+	dashboard.Handle(events.Event{Type: events.CommandRetrying, Index: 0, Attempt: 2})
+	dashboard.Handle(events.Event{Type: events.CommandStarted, Index: 0, Time: started.Add(time.Second)})
+	dashboard.Handle(events.Event{Type: events.OutputLine, Index: 0, Line: strings.Repeat("x", 100)})
+
+	got := dashboard.render(started.Add(1500 * time.Millisecond))[0]
+
+	if !strings.Contains(got, "try 2") || !strings.Contains(got, "1.5s") {
+		t.Errorf("Expected the attempt and time since the first start, got %q", got)
+	}
+	if width := len([]rune(got)); width >= 40 {
+		t.Errorf("Row should fit into the terminal width, got %d chars: %q", width, got)
+	}
+	if !strings.HasSuffix(got, "x…") {
+		t.Errorf("Long output line should be cut, got %q", got)
+	}
+}
+
+func TestDashboard_RenderHeightLimit(t *testing.T) {
+	names := []string{"a", "b", "c", "d", "e", "f"}
+	dashboard := NewDashboard(&bytes.Buffer{}, names, 80, 5)
+	for _, index := range []int{0, 1} {
+		dashboard.Handle(events.Event{Type: events.CommandFinished, Index: index, Result: &types.CommandResult{IsSuccess: true}})
+	}
+
+	got := dashboard.render(time.Now())
+
+	if len(got) != 3 {
+		t.Fatalf("Expected rows to fit into height-2 lines, got %d:\n%s", len(got), strings.Join(got, "\n"))
+	}
+	if !strings.HasPrefix(got[0], "· c") || got[2] != "… 2 more, 2 passed" {
+		t.Errorf("Passed commands should be hidden first, got:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestDashboard_RenderOnlyPassedHidden(t *testing.T) {
+	names := []string{"a", "b", "c", "d", "e", "f"}
+	dashboard := NewDashboard(&bytes.Buffer{}, names, 80, 6)
+	for _, index := range []int{0, 1, 2} {
+		dashboard.Handle(events.Event{Type: events.CommandFinished, Index: index, Result: &types.CommandResult{IsSuccess: true}})
+	}
+
+	got := dashboard.render(time.Now())
+
+	if len(got) != 4 || got[3] != "… 3 passed" {
+		t.Errorf("Overflow line should not mention 0 more, got:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestDashboard_StopErasesTable(t *testing.T) {
+	var out bytes.Buffer
+	dashboard := NewDashboard(&out, []string{"lint", "test"}, 80, 0)
+
+	dashboard.Start()
+	dashboard.Stop()
+	dashboard.Stop()
+
+	got := out.String()
+	if !strings.Contains(got, "· lint") || !strings.HasSuffix(got, "\x1b[2A\x1b[J\x1b[?25h") {
+		t.Errorf("Expected the table to be drawn and then erased, got %q", got)
+	}
+}
+
+func TestFormatElapsed(t *testing.T) {
+	tests := []struct {
+		elapsed time.Duration
+		want    string
+	}{
+		{850 * time.Millisecond, "850ms"},
+		{12340 * time.Millisecond, "12.3s"},
+		{125 * time.Second, "2m05s"},
+	}
+
+	for _, tt := range tests {
+		if got := formatElapsed(tt.elapsed); got != tt.want {
+			t.Errorf("formatElapsed(%s) = %q, want %q", tt.elapsed, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package reporter
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/events"
+)
+
+// ndjsonEvent - JSON представление события, поля заполняются в зависимости от типа
+type ndjsonEvent struct {
+	Type            events.Type  `json:"type"`
+	Time            time.Time    `json:"time"`
+	Index           *int         `json:"index,omitempty"`
+	Command         string       `json:"command,omitempty"`
+	Commands        []string     `json:"commands,omitempty"`
+	Threads         int          `json:"threads,omitempty"`
+	ResolvedCommand string       `json:"resolved_command,omitempty"`
+	Pid             int          `json:"pid,omitempty"`
+	Stream          string       `json:"stream,omitempty"`
+	Line            *string      `json:"line,omitempty"`
+	Partial         bool         `json:"partial,omitempty"`
+	Status          string       `json:"status,omitempty"`
+	ExitCode        *int         `json:"exit_code,omitempty"`
+	Signal          string       `json:"signal,omitempty"`
+	FailureKind     string       `json:"failure_kind,omitempty"`
+	Reason          string       `json:"reason,omitempty"`
+	DurationMs      *int64       `json:"duration_ms,omitempty"`
+	Attempt         int          `json:"attempt,omitempty"`
+	Attempts        int          `json:"attempts,omitempty"`
+	DelayMs         *int64       `json:"delay_ms,omitempty"`
+	Summary         *JSONSummary `json:"summary,omitempty"`
+}
+
+func toNDJSONEvent(event events.Event) ndjsonEvent {
+	encoded := ndjsonEvent{
+		Type:            event.Type,
+		Time:            event.Time,
+		Command:         event.Command,
+		Commands:        event.Commands,
+		Threads:         event.Threads,
+		ResolvedCommand: event.ResolvedCommand,
+		Pid:             event.Pid,
+		Stream:          event.Stream,
+	}
+
+	if event.Index >= 0 {
+		index := event.Index
+		encoded.Index = &index
+	}
+
+	switch event.Type {
+	case events.OutputLine:
+		line := event.Line
+		encoded.Line = &line
+		encoded.Partial = event.Partial
+	case events.CommandRetrying:
+		delayMs := event.Delay.Milliseconds()
+		encoded.Attempt = event.Attempt
+		encoded.DelayMs = &delayMs
+		if result := event.Result; result != nil {
+			exitCode := result.ExitCode
+			encoded.ExitCode = &exitCode
+			encoded.Signal = result.Signal
+			encoded.FailureKind = string(result.FailureKind)
+			encoded.Reason = result.Reason
+		}
+	case events.CommandFinished:
+		if result := event.Result; result != nil {
+			exitCode := result.ExitCode
+			durationMs := result.Duration.Milliseconds()
+
+			encoded.Status = resultStatus(*result)
+			encoded.ExitCode = &exitCode
+			encoded.Signal = result.Signal
+			encoded.FailureKind = string(result.FailureKind)
+			encoded.Reason = result.Reason
+			encoded.DurationMs = &durationMs
+			encoded.Attempts = len(result.Attempts)
+		}
+	case events.RunFinished:
+		summary := buildSummary(event.Results)
+		encoded.Summary = &summary
+	}
+
+	return encoded
+}
+
+// NewNDJSONWriter возвращает обработчик, который пишет каждое событие отдельной JSON строкой
+func NewNDJSONWriter(w io.Writer) events.Handler {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	return func(event events.Event) {
+		_ = encoder.Encode(toNDJSONEvent(event))
+	}
+}
@@ -0,0 +1,53 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/events"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+func TestNewNDJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	write := NewNDJSONWriter(&buf)
+
+	result := types.CommandResult{Command: "lint", IsSuccess: false, ExitCode: 1, FailureKind: types.FailureExit, Duration: 20 * time.Millisecond}
+
+	write(events.Event{Type: events.RunStarted, Index: -1, Commands: []string{"lint"}, Threads: 2})
+	write(events.Event{Type: events.OutputLine, Index: 0, Command: "lint", Stream: events.StreamStderr, Line: ""})
+	write(events.Event{Type: events.CommandFinished, Index: 0, Command: "lint", Result: &result})
+	write(events.Event{Type: events.RunFinished, Index: -1, Results: []types.CommandResult{result}})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 NDJSON lines, got %d: %q", len(lines), buf.String())
+	}
+
+	decoded := make([]map[string]any, len(lines))
+	for i, line := range lines {
+		if err := json.Unmarshal([]byte(line), &decoded[i]); err != nil {
+			t.Fatalf("Line %d is not valid JSON: %v", i, err)
+		}
+	}
+
+	if _, ok := decoded[0]["index"]; ok {
+		t.Error("Run level events should not contain index")
+	}
+
+	if line, ok := decoded[1]["line"]; !ok || line != "" || decoded[1]["stream"] != "stderr" {
+		t.Errorf("Empty output line should be preserved, got %v", decoded[1])
+	}
+
+	if decoded[2]["status"] != "failed" || decoded[2]["exit_code"] != float64(1) || decoded[2]["index"] != float64(0) {
+		t.Errorf("Unexpected command_finished event: %v", decoded[2])
+	}
+
+	summary, ok := decoded[3]["summary"].(map[string]any)
+	if !ok || summary["failed"] != float64(1) {
+		t.Errorf("Unexpected run_finished event: %v", decoded[3])
+	}
+}
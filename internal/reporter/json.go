@@ -0,0 +1,290 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/diagnostics"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+// JSONReport - машиночитаемый отчет о запуске
+type JSONReport struct {
+	Run      JSONRunInfo   `json:"run"`
+	Summary  JSONSummary   `json:"summary"`
+	Commands []JSONCommand `json:"commands"`
+}
+
+// JSONRunInfo содержит метаданные запуска
+type JSONRunInfo struct {
+	Version    string    `json:"version"`
+	Cwd        string    `json:"cwd"`
+	Threads    int       `json:"threads"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMs int64     `json:"duration_ms"`
+	LogDir     string    `json:"log_dir,omitempty"`
+}
+
+// JSONSummary содержит итоговую статистику
+type JSONSummary struct {
+	Success bool `json:"success"`
+	Total   int  `json:"total"`
+	Passed  int  `json:"passed"`
+	Failed  int  `json:"failed"`
+	Skipped int  `json:"skipped"`
+	// PassedAfterRetry считает нестабильные команды, которые входят и в Passed
+	PassedAfterRetry int `json:"passed_after_retry"`
+}
+
+// JSONCommand содержит результат одной команды
+type JSONCommand struct {
+	Command          string           `json:"command"`
+	ResolvedCommand  string           `json:"resolved_command,omitempty"`
+	Shell            bool             `json:"shell"`
+	Status           string           `json:"status"`
+	ExitCode         int              `json:"exit_code"`
+	Signal           string           `json:"signal,omitempty"`
+	FailureKind      string           `json:"failure_kind,omitempty"`
+	Reason           string           `json:"reason,omitempty"`
+	DurationMs       int64            `json:"duration_ms"`
+	StartedAt        time.Time        `json:"started_at,omitzero"`
+	FinishedAt       time.Time        `json:"finished_at,omitzero"`
+	Stdout           string           `json:"stdout"`
+	Stderr           string           `json:"stderr"`
+	Output           []JSONOutput     `json:"output,omitempty"`
+	OmittedLines     int              `json:"omitted_lines,omitempty"`
+	FullLog          string           `json:"full_log,omitempty"`
+	Logs             *JSONLogFiles    `json:"logs,omitempty"`
+	OutputError      string           `json:"output_error,omitempty"`
+	Cwd              string           `json:"cwd,omitempty"`
+	Env              []string         `json:"env,omitempty"`
+	EnvFiles         []string         `json:"env_files,omitempty"`
+	EnvAllow         []string         `json:"env_allow,omitempty"`
+	CleanEnv         bool             `json:"clean_env,omitempty"`
+	PassedAfterRetry bool             `json:"passed_after_retry,omitempty"`
+	Attempts         []JSONAttempt    `json:"attempts,omitempty"`
+	Diagnostics      []JSONDiagnostic `json:"diagnostics,omitempty"`
+}
+
+// JSONOutput - кусок вывода команды одного потока; куски идут в порядке чтения
+type JSONOutput struct {
+	Stream string    `json:"stream"`
+	Time   time.Time `json:"time,omitzero"`
+	Text   string    `json:"text"`
+}
+
+// JSONLogFiles - пути к логам команды, сохраненным при --log-dir
+type JSONLogFiles struct {
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	Combined string `json:"combined"`
+}
+
+// JSONDiagnostic - ошибка или предупреждение, разобранные из вывода команды
+type JSONDiagnostic struct {
+	Tool     string `json:"tool"`
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity"`
+	Rule     string `json:"rule,omitempty"`
+	Message  string `json:"message"`
+}
+
+// buildJSONDiagnostics разбирает вывод упавшей команды в диагностики; nil, если команда прошла
+// или формат не распознан: строки логов успешной команды не должны выглядеть как ошибки
+func buildJSONDiagnostics(result types.CommandResult) []JSONDiagnostic {
+	if result.IsSuccess {
+		return nil
+	}
+
+	found := diagnostics.Parse(result.CombinedOutput())
+	if len(found) == 0 {
+		return nil
+	}
+
+	diagnosticsJSON := make([]JSONDiagnostic, len(found))
+	for i, diagnostic := range found {
+		diagnosticsJSON[i] = JSONDiagnostic{
+			Tool:     diagnostic.Tool,
+			File:     diagnostic.File,
+			Line:     diagnostic.Line,
+			Column:   diagnostic.Column,
+			Severity: diagnostic.Severity,
+			Rule:     diagnostic.Rule,
+			Message:  diagnostic.Message,
+		}
+	}
+	return diagnosticsJSON
+}
+
+// JSONAttempt описывает одну попытку команды при --retries
+type JSONAttempt struct {
+	ExitCode    int    `json:"exit_code"`
+	Signal      string `json:"signal,omitempty"`
+	FailureKind string `json:"failure_kind,omitempty"`
+	DurationMs  int64  `json:"duration_ms"`
+}
+
+// buildJSONAttempts преобразует попытки команды в JSON структуры
+func buildJSONAttempts(attempts []types.Attempt) []JSONAttempt {
+	if len(attempts) == 0 {
+		return nil
+	}
+
+	encoded := make([]JSONAttempt, len(attempts))
+	for i, attempt := range attempts {
+		encoded[i] = JSONAttempt{
+			ExitCode:    attempt.ExitCode,
+			Signal:      attempt.Signal,
+			FailureKind: string(attempt.FailureKind),
+			DurationMs:  attempt.Duration.Milliseconds(),
+		}
+	}
+	return encoded
+}
+
+// resultStatus возвращает статус команды: passed, failed или skipped
+func resultStatus(result types.CommandResult) string {
+	switch {
+	case result.IsSuccess:
+		return "passed"
+	case result.FailureKind == types.FailureSkipped:
+		return "skipped"
+	default:
+		return "failed"
+	}
+}
+
+// buildSummary считает итоговую статистику по результатам
+func buildSummary(results []types.CommandResult) JSONSummary {
+	summary := JSONSummary{
+		Success: AllPassed(results),
+		Total:   len(results),
+	}
+
+	for _, result := range results {
+		if result.PassedAfterRetry() {
+			summary.PassedAfterRetry++
+		}
+
+		switch resultStatus(result) {
+		case "passed":
+			summary.Passed++
+		case "skipped":
+			summary.Skipped++
+		default:
+			summary.Failed++
+		}
+	}
+
+	return summary
+}
+
+// BuildJSONReport собирает JSON отчет из результатов и метаданных запуска
+func BuildJSONReport(results []types.CommandResult, info types.RunInfo) JSONReport {
+	report := JSONReport{
+		Run: JSONRunInfo{
+			Version:    info.Version,
+			Cwd:        info.Cwd,
+			Threads:    info.Threads,
+			StartedAt:  info.StartedAt,
+			FinishedAt: info.FinishedAt,
+			DurationMs: info.FinishedAt.Sub(info.StartedAt).Milliseconds(),
+			LogDir:     info.LogDir,
+		},
+		Summary:  buildSummary(results),
+		Commands: make([]JSONCommand, 0, len(results)),
+	}
+
+	for _, result := range results {
+		report.Commands = append(report.Commands, JSONCommand{
+			Command:          result.Command,
+			ResolvedCommand:  result.ResolvedCommand,
+			Shell:            result.Shell,
+			Status:           resultStatus(result),
+			ExitCode:         result.ExitCode,
+			Signal:           result.Signal,
+			FailureKind:      string(result.FailureKind),
+			Reason:           result.Reason,
+			DurationMs:       result.Duration.Milliseconds(),
+			StartedAt:        result.StartedAt,
+			FinishedAt:       result.FinishedAt,
+			Stdout:           result.Stdout,
+			Stderr:           result.Stderr,
+			Output:           buildJSONOutput(result.Output),
+			OmittedLines:     result.OmittedLines,
+			FullLog:          result.FullLog,
+			Logs:             buildJSONLogFiles(result.Logs),
+			OutputError:      result.OutputError,
+			Cwd:              result.Dir,
+			Env:              result.Env,
+			EnvFiles:         result.EnvFiles,
+			EnvAllow:         result.EnvAllow,
+			CleanEnv:         result.CleanEnv,
+			PassedAfterRetry: result.PassedAfterRetry(),
+			Attempts:         buildJSONAttempts(result.Attempts),
+			Diagnostics:      buildJSONDiagnostics(result),
+		})
+	}
+
+	return report
+}
+
+// buildJSONOutput переводит вывод команды в порядке чтения в JSON
+func buildJSONOutput(chunks []types.OutputChunk) []JSONOutput {
+	if len(chunks) == 0 {
+		return nil
+	}
+	output := make([]JSONOutput, len(chunks))
+	for i, chunk := range chunks {
+		output[i] = JSONOutput{Stream: chunk.Stream, Time: chunk.Time, Text: chunk.Text}
+	}
+	return output
+}
+
+// buildJSONLogFiles переводит пути логов команды в JSON; nil, если логи не сохранялись
+func buildJSONLogFiles(files *types.LogFiles) *JSONLogFiles {
+	if files == nil {
+		return nil
+	}
+	return &JSONLogFiles{Stdout: files.Stdout, Stderr: files.Stderr, Combined: files.Combined}
+}
+
+// WriteJSONReport пишет JSON отчет в writer
+func WriteJSONReport(w io.Writer, results []types.CommandResult, info types.RunInfo) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	return encoder.Encode(BuildJSONReport(results, info))
+}
+
+// PrintJSONReport выводит JSON отчет в stdout
+func PrintJSONReport(results []types.CommandResult, info types.RunInfo) error {
+	return WriteJSONReport(os.Stdout, results, info)
+}
+
+// WriteJSONReportFile сохраняет JSON отчет в файл, создавая недостающие директории
+func WriteJSONReportFile(path string, results []types.CommandResult, info types.RunInfo) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+
+	if err := WriteJSONReport(file, results, info); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write report file: %w", err)
+	}
+
+	return file.Close()
+}
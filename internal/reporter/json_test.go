@@ -0,0 +1,172 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+func TestBuildJSONReport(t *testing.T) {
+	started := time.Date(2025, 11, 4, 10, 0, 0, 0, time.UTC)
+	results := []types.CommandResult{
+		{Command: "lint", ResolvedCommand: "yarn run lint", IsSuccess: true, Duration: 120 * time.Millisecond, Stdout: "ok\n"},
+		{Command: "test", ResolvedCommand: "yarn run test", IsSuccess: false, ExitCode: 2, FailureKind: types.FailureExit, Stderr: "fail\n"},
+		{Command: "e2e", IsSuccess: false, ExitCode: -1, FailureKind: types.FailureSkipped, Reason: "global timeout expired while queued"},
+	}
+	info := types.RunInfo{Version: "1.0.0", Cwd: "/repo", Threads: 2, StartedAt: started, FinishedAt: started.Add(time.Second)}
+
+	report := BuildJSONReport(results, info)
+
+	if report.Run.DurationMs != 1000 || report.Run.Version != "1.0.0" || report.Run.Threads != 2 {
+		t.Errorf("Unexpected run metadata: %+v", report.Run)
+	}
+
+	want := JSONSummary{Success: false, Total: 3, Passed: 1, Failed: 1, Skipped: 1}
+	if report.Summary != want {
+		t.Errorf("Summary = %+v, want %+v", report.Summary, want)
+	}
+
+	if report.Commands[0].ResolvedCommand != "yarn run lint" || report.Commands[0].DurationMs != 120 {
+		t.Errorf("Unexpected first command: %+v", report.Commands[0])
+	}
+
+	if report.Commands[1].Status != "failed" || report.Commands[1].ExitCode != 2 || report.Commands[1].FailureKind != "exit" {
+		t.Errorf("Unexpected second command: %+v", report.Commands[1])
+	}
+
+	if report.Commands[2].Status != "skipped" {
+		t.Errorf("Unexpected third command status: %q", report.Commands[2].Status)
+	}
+}
+
+func TestWriteJSONReport_ValidJSON(t *testing.T) {
+	results := []types.CommandResult{
+		{Command: "echo \"<tag>\"", IsSuccess: true, Stdout: "<tag>\n"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSONReport(&buf, results, types.RunInfo{}); err != nil {
+		t.Fatalf("WriteJSONReport() error = %v", err)
+	}
+
+	var decoded JSONReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Report is not valid JSON: %v", err)
+	}
+
+	if decoded.Commands[0].Stdout != "<tag>\n" {
+		t.Errorf("Stdout = %q, want %q", decoded.Commands[0].Stdout, "<tag>\n")
+	}
+}
+
+func TestWriteJSONReportFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "report.json")
+	results := []types.CommandResult{{Command: "lint", IsSuccess: true}}
+
+	if err := WriteJSONReportFile(path, results, types.RunInfo{}); err != nil {
+		t.Fatalf("WriteJSONReportFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Report file not created: %v", err)
+	}
+
+	if !json.Valid(data) {
+		t.Error("Report file should contain valid JSON")
+	}
+}
+
+func TestBuildJSONReport_Attempts(t *testing.T) {
+	results := []types.CommandResult{
+		{
+			Command:   "yarn e2e",
+			IsSuccess: true,
+			Attempts: []types.Attempt{
+				{ExitCode: 1, FailureKind: types.FailureExit, Duration: 2 * time.Second},
+				{ExitCode: 0, Duration: time.Second},
+			},
+		},
+	}
+
+	report := BuildJSONReport(results, types.RunInfo{})
+	command := report.Commands[0]
+
+	if report.Summary.PassedAfterRetry != 1 || report.Summary.Passed != 1 {
+		t.Errorf("Summary = %+v, want 1 passed after retry", report.Summary)
+	}
+	if !command.PassedAfterRetry || len(command.Attempts) != 2 || command.Attempts[0].DurationMs != 2000 {
+		t.Errorf("Command = %+v, want attempts recorded", command)
+	}
+}
+
+func TestBuildJSONReport_Diagnostics(t *testing.T) {
+	results := []types.CommandResult{
+		{Command: "go vet ./...", ExitCode: 1, FailureKind: types.FailureExit, Stderr: "# app\n./main.go:12:5: undefined: foo\n"},
+		{Command: "echo ok", IsSuccess: true, Stdout: "ok\n"},
+		{Command: "go run ./server", IsSuccess: true, Stderr: "./server.go:42:3: listening on :8080\n"},
+	}
+
+	report := BuildJSONReport(results, types.RunInfo{})
+
+	want := []JSONDiagnostic{{Tool: "go", File: "./main.go", Line: 12, Column: 5, Severity: "error", Message: "undefined: foo"}}
+	if !reflect.DeepEqual(report.Commands[0].Diagnostics, want) {
+		t.Errorf("Diagnostics = %+v, want %+v", report.Commands[0].Diagnostics, want)
+	}
+	if report.Commands[1].Diagnostics != nil {
+		t.Errorf("Unrecognized output should have no diagnostics, got %+v", report.Commands[1].Diagnostics)
+	}
+	if report.Commands[2].Diagnostics != nil {
+		t.Errorf("Passed command should have no diagnostics, got %+v", report.Commands[2].Diagnostics)
+	}
+}
+
+func TestBuildJSONReport_Logs(t *testing.T) {
+	logs := &types.LogFiles{Stdout: "runs/1/1-test.stdout.log", Stderr: "runs/1/1-test.stderr.log", Combined: "runs/1/1-test.log"}
+	results := []types.CommandResult{
+		{Command: "test", ExitCode: 1, FailureKind: types.FailureExit, Logs: logs},
+		{Command: "e2e", ExitCode: -1, FailureKind: types.FailureSkipped},
+	}
+
+	report := BuildJSONReport(results, types.RunInfo{LogDir: "runs/1"})
+
+	if report.Run.LogDir != "runs/1" {
+		t.Errorf("LogDir = %q, want runs/1", report.Run.LogDir)
+	}
+	want := &JSONLogFiles{Stdout: logs.Stdout, Stderr: logs.Stderr, Combined: logs.Combined}
+	if !reflect.DeepEqual(report.Commands[0].Logs, want) {
+		t.Errorf("Logs = %+v, want %+v", report.Commands[0].Logs, want)
+	}
+	if report.Commands[1].Logs != nil {
+		t.Errorf("Command without logs should omit them, got %+v", report.Commands[1].Logs)
+	}
+}
+
+func TestBuildJSONReport_Output(t *testing.T) {
+	started := time.Date(2025, 11, 4, 10, 0, 0, 0, time.UTC)
+	results := []types.CommandResult{{
+		Command: "test",
+		Stdout:  "a\nc\n",
+		Stderr:  "b\n",
+		Output: []types.OutputChunk{
+			{Stream: "stdout", Time: started, Text: "a\n"},
+			{Stream: "stderr", Time: started.Add(time.Millisecond), Text: "b\n"},
+			{Stream: "stdout", Time: started.Add(2 * time.Millisecond), Text: "c\n"},
+		},
+	}}
+
+	command := BuildJSONReport(results, types.RunInfo{}).Commands[0]
+
+	if len(command.Output) != 3 || command.Output[1] != (JSONOutput{Stream: "stderr", Time: started.Add(time.Millisecond), Text: "b\n"}) {
+		t.Errorf("Output = %+v", command.Output)
+	}
+	if command.Stdout != "a\nc\n" || command.Stderr != "b\n" {
+		t.Errorf("Separate streams should stay in the report, got %q and %q", command.Stdout, command.Stderr)
+	}
+}
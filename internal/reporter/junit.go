@@ -0,0 +1,401 @@
+package reporter
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// JUnitTestSuites - корневой элемент JUnit отчета
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite соответствует одной команде
+type JUnitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase - команда целиком или отдельный тест, извлеченный из её вывода
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *JUnitMessage `xml:"failure,omitempty"`
+	Error     *JUnitMessage `xml:"error,omitempty"`
+	Skipped   *JUnitMessage `xml:"skipped,omitempty"`
+	// FlakyFailures - неудачные попытки команды, прошедшей после повтора (расширение Maven Surefire)
+	FlakyFailures []JUnitMessage `xml:"flakyFailure,omitempty"`
+	SystemOut     *JUnitOutput   `xml:"system-out,omitempty"`
+	SystemErr     *JUnitOutput   `xml:"system-err,omitempty"`
+}
+
+// JUnitMessage - содержимое элементов failure, error и skipped
+type JUnitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",cdata"`
+}
+
+// JUnitOutput - содержимое system-out и system-err
+type JUnitOutput struct {
+	Text string `xml:",cdata"`
+}
+
+// newJUnitOutput возвращает nil для пустого вывода, чтобы элемент не попадал в отчет
+func newJUnitOutput(text string) *JUnitOutput {
+	if text == "" {
+		return nil
+	}
+	return &JUnitOutput{Text: sanitizeXMLText(text)}
+}
+
+// newJUnitMessage очищает текст и сообщение от символов, недопустимых в XML
+func newJUnitMessage(message, messageType, text string) *JUnitMessage {
+	return &JUnitMessage{
+		Message: sanitizeXMLText(message),
+		Type:    messageType,
+		Text:    sanitizeXMLText(text),
+	}
+}
+
+// BuildJUnitReport превращает результаты в JUnit отчет; вывод go test -json и jest --json раскрывается в отдельные тесты
+func BuildJUnitReport(results []types.CommandResult) JUnitTestSuites {
+	report := JUnitTestSuites{Name: "aifr"}
+	total := time.Duration(0)
+
+	for _, result := range results {
+		suite := buildJUnitSuite(result)
+
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Skipped += suite.Skipped
+		report.Suites = append(report.Suites, suite)
+
+		if result.Duration > total {
+			total = result.Duration
+		}
+	}
+	report.Time = formatSeconds(total)
+
+	return report
+}
+
+func buildJUnitSuite(result types.CommandResult) JUnitTestSuite {
+	name := cleanCommandName(result.Command)
+	suite := JUnitTestSuite{Name: name, Time: formatSeconds(result.Duration)}
+	if !result.StartedAt.IsZero() {
+		suite.Timestamp = result.StartedAt.Format("2006-01-02T15:04:05")
+	}
+
+	cases := parseGoTestJSON(result.Stdout)
+	if len(cases) == 0 {
+		cases = parseJestJSON(result.Stdout)
+	}
+
+	if len(cases) == 0 || (!result.IsSuccess && !hasFailedCase(cases)) {
+		cases = append(cases, commandTestCase(result, name))
+	}
+
+	for _, testCase := range cases {
+		suite.Tests++
+		switch {
+		case testCase.Failure != nil:
+			suite.Failures++
+		case testCase.Error != nil:
+			suite.Errors++
+		case testCase.Skipped != nil:
+			suite.Skipped++
+		}
+	}
+	suite.Cases = cases
+
+	return suite
+}
+
+// commandTestCase описывает команду целиком как один testcase
+func commandTestCase(result types.CommandResult, name string) JUnitTestCase {
+	testCase := JUnitTestCase{
+		Name:      name,
+		Classname: "aifr",
+		Time:      formatSeconds(result.Duration),
+		SystemOut: newJUnitOutput(result.Stdout),
+		SystemErr: newJUnitOutput(result.Stderr),
+	}
+
+	if result.IsSuccess {
+		if result.PassedAfterRetry() {
+			for _, attempt := range result.Attempts[:len(result.Attempts)-1] {
+				testCase.FlakyFailures = append(testCase.FlakyFailures, *newJUnitMessage(describeAttempt(attempt), string(attempt.FailureKind), ""))
+			}
+		}
+		return testCase
+	}
+
+	message := describeFailure(result)
+	if message == "" {
+		message = "command failed"
+	}
+
+	switch result.FailureKind {
+	case types.FailureSkipped:
+		testCase.Skipped = newJUnitMessage(message, "", "")
+	case types.FailureStart:
+		testCase.Error = newJUnitMessage(message, string(result.FailureKind), result.Stderr)
+	default:
+		text := result.Stderr
+		if text == "" {
+			text = result.Stdout
+		}
+		testCase.Failure = newJUnitMessage(firstLine(text, message), string(result.FailureKind), text)
+	}
+
+	return testCase
+}
+
+func hasFailedCase(cases []JUnitTestCase) bool {
+	for _, testCase := range cases {
+		if testCase.Failure != nil || testCase.Error != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// goTestEvent - строка вывода go test -json
+type goTestEvent struct {
+	Action  string  `json:"Action"`
+	Package string  `json:"Package"`
+	Test    string  `json:"Test"`
+	Elapsed float64 `json:"Elapsed"`
+	Output  string  `json:"Output"`
+}
+
+// parseGoTestJSON извлекает тесты из вывода go test -json
+func parseGoTestJSON(stdout string) []JUnitTestCase {
+	if !strings.Contains(stdout, `"Action"`) {
+		return nil
+	}
+
+	type goTest struct {
+		event  goTestEvent
+		output strings.Builder
+	}
+
+	tests := map[string]*goTest{}
+	order := []string{}
+
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var event goTestEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil || event.Action == "" || event.Test == "" {
+			continue
+		}
+
+		key := event.Package + "\x00" + event.Test
+		test, ok := tests[key]
+		if !ok {
+			test = &goTest{}
+			tests[key] = test
+			order = append(order, key)
+		}
+
+		switch event.Action {
+		case "output":
+			test.output.WriteString(event.Output)
+		case "pass", "fail", "skip":
+			test.event = event
+		}
+	}
+
+	cases := []JUnitTestCase{}
+	for _, key := range order {
+		test := tests[key]
+		if test.event.Action == "" {
+			continue
+		}
+
+		output := test.output.String()
+		testCase := JUnitTestCase{
+			Name:      test.event.Test,
+			Classname: test.event.Package,
+			Time:      fmt.Sprintf("%.3f", test.event.Elapsed),
+		}
+
+		switch test.event.Action {
+		case "fail":
+			testCase.Failure = newJUnitMessage(firstLine(goTestMessages(output), "test failed"), "", output)
+		case "skip":
+			testCase.Skipped = newJUnitMessage(firstLine(goTestMessages(output), "skipped"), "", "")
+		default:
+			testCase.SystemOut = newJUnitOutput(output)
+		}
+
+		cases = append(cases, testCase)
+	}
+
+	return cases
+}
+
+// jestReport - часть вывода jest --json (и совместимого vitest --reporter=json)
+type jestReport struct {
+	TestResults []struct {
+		Name             string `json:"name"`
+		AssertionResults []struct {
+			FullName        string   `json:"fullName"`
+			Title           string   `json:"title"`
+			Status          string   `json:"status"`
+			Duration        *float64 `json:"duration"`
+			FailureMessages []string `json:"failureMessages"`
+		} `json:"assertionResults"`
+	} `json:"testResults"`
+}
+
+// parseJestJSON извлекает тесты из вывода jest --json
+func parseJestJSON(stdout string) []JUnitTestCase {
+	start := strings.Index(stdout, "{")
+	if start < 0 || !strings.Contains(stdout, `"testResults"`) {
+		return nil
+	}
+
+	var report jestReport
+	if err := json.Unmarshal([]byte(stdout[start:]), &report); err != nil {
+		return nil
+	}
+
+	cases := []JUnitTestCase{}
+	for _, file := range report.TestResults {
+		for _, assertion := range file.AssertionResults {
+			name := assertion.FullName
+			if name == "" {
+				name = assertion.Title
+			}
+
+			duration := 0.0
+			if assertion.Duration != nil {
+				duration = *assertion.Duration / 1000
+			}
+
+			testCase := JUnitTestCase{
+				Name:      name,
+				Classname: file.Name,
+				Time:      fmt.Sprintf("%.3f", duration),
+			}
+
+			switch assertion.Status {
+			case "failed":
+				text := strings.Join(assertion.FailureMessages, "\n")
+				testCase.Failure = newJUnitMessage(firstLine(text, "test failed"), "", text)
+			case "pending", "skipped", "todo", "disabled":
+				testCase.Skipped = newJUnitMessage(assertion.Status, "", "")
+			}
+
+			cases = append(cases, testCase)
+		}
+	}
+
+	return cases
+}
+
+// goTestMessages убирает из вывода теста служебные строки "=== RUN" и "--- FAIL"
+func goTestMessages(output string) string {
+	lines := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- ") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func firstLine(text, fallback string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			return trimmed
+		}
+	}
+	return fallback
+}
+
+// sanitizeXMLText удаляет ANSI escape-последовательности и символы, недопустимые в XML 1.0
+func sanitizeXMLText(text string) string {
+	text = ansiPattern.ReplaceAllString(text, "")
+
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			return r
+		case r < 0x20, r == 0xFFFE, r == 0xFFFF, r >= 0xD800 && r <= 0xDFFF:
+			return -1
+		}
+		return r
+	}, text)
+}
+
+func formatSeconds(duration time.Duration) string {
+	return fmt.Sprintf("%.3f", duration.Seconds())
+}
+
+// WriteJUnitReport пишет JUnit XML в writer
+func WriteJUnitReport(w io.Writer, results []types.CommandResult) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(BuildJUnitReport(results)); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteJUnitReportFile сохраняет JUnit XML в файл, создавая недостающие директории
+func WriteJUnitReportFile(path string, results []types.CommandResult) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create junit directory: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create junit file: %w", err)
+	}
+
+	if err := WriteJUnitReport(file, results); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write junit file: %w", err)
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,130 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+func TestBuildJUnitReport_Commands(t *testing.T) {
+	results := []types.CommandResult{
+		{Command: "yarn lint", IsSuccess: true, Duration: 1500 * time.Millisecond, Stdout: "ok\n"},
+		{Command: "test", IsSuccess: false, ExitCode: 1, FailureKind: types.FailureExit, Stderr: "Error: boom\nat line 1\n"},
+		{Command: "e2e", IsSuccess: false, ExitCode: -1, FailureKind: types.FailureSkipped, Reason: "global timeout expired while queued"},
+		{Command: "missing", IsSuccess: false, ExitCode: -1, FailureKind: types.FailureStart, Stderr: "not found\n"},
+	}
+
+	report := BuildJUnitReport(results)
+
+	if report.Tests != 4 || report.Failures != 1 || report.Skipped != 1 || report.Errors != 1 {
+		t.Errorf("Unexpected totals: tests=%d failures=%d skipped=%d errors=%d", report.Tests, report.Failures, report.Skipped, report.Errors)
+	}
+
+	lint := report.Suites[0].Cases[0]
+	if lint.Name != "lint" || lint.Time != "1.500" || lint.SystemOut == nil || lint.SystemOut.Text != "ok\n" {
+		t.Errorf("Unexpected lint testcase: %+v", lint)
+	}
+
+	failure := report.Suites[1].Cases[0].Failure
+	if failure == nil || failure.Message != "Error: boom" || !strings.Contains(failure.Text, "at line 1") {
+		t.Errorf("Unexpected failure: %+v", failure)
+	}
+}
+
+func TestBuildJUnitReport_GoTestJSON(t *testing.T) {
+	stdout := strings.Join([]string{
+		`{"Action":"run","Package":"pkg/a","Test":"TestOK"}`,
+		`{"Action":"output","Package":"pkg/a","Test":"TestOK","Output":"=== RUN   TestOK\n"}`,
+		`{"Action":"pass","Package":"pkg/a","Test":"TestOK","Elapsed":0.01}`,
+		`{"Action":"run","Package":"pkg/a","Test":"TestBad"}`,
+		`{"Action":"output","Package":"pkg/a","Test":"TestBad","Output":"    a_test.go:10: expected 1\n"}`,
+		`{"Action":"fail","Package":"pkg/a","Test":"TestBad","Elapsed":0.2}`,
+		`{"Action":"fail","Package":"pkg/a","Elapsed":0.3}`,
+	}, "\n")
+	results := []types.CommandResult{
+		{Command: "go test -json ./...", IsSuccess: false, ExitCode: 1, FailureKind: types.FailureExit, Stdout: stdout},
+	}
+
+	suite := BuildJUnitReport(results).Suites[0]
+
+	if suite.Tests != 2 || suite.Failures != 1 {
+		t.Fatalf("Expected 2 tests with 1 failure, got tests=%d failures=%d", suite.Tests, suite.Failures)
+	}
+
+	bad := suite.Cases[1]
+	if bad.Name != "TestBad" || bad.Classname != "pkg/a" || bad.Time != "0.200" {
+		t.Errorf("Unexpected testcase: %+v", bad)
+	}
+	if bad.Failure == nil || bad.Failure.Message != "a_test.go:10: expected 1" {
+		t.Errorf("Unexpected failure: %+v", bad.Failure)
+	}
+}
+
+func TestBuildJUnitReport_JestJSON(t *testing.T) {
+	stdout := `{"numFailedTests":1,"testResults":[{"name":"/app/sum.test.js","assertionResults":[
+		{"fullName":"sum adds","status":"passed","duration":5,"failureMessages":[]},
+		{"fullName":"sum fails","status":"failed","duration":12,"failureMessages":["Expected 3, received 4"]},
+		{"fullName":"sum later","status":"pending","failureMessages":[]}
+	]}]}`
+	results := []types.CommandResult{
+		{Command: "jest --json", IsSuccess: false, ExitCode: 1, FailureKind: types.FailureExit, Stdout: stdout},
+	}
+
+	suite := BuildJUnitReport(results).Suites[0]
+
+	if suite.Tests != 3 || suite.Failures != 1 || suite.Skipped != 1 {
+		t.Fatalf("Unexpected totals: tests=%d failures=%d skipped=%d", suite.Tests, suite.Failures, suite.Skipped)
+	}
+
+	if suite.Cases[1].Classname != "/app/sum.test.js" || suite.Cases[1].Time != "0.012" {
+		t.Errorf("Unexpected testcase: %+v", suite.Cases[1])
+	}
+}
+
+func TestWriteJUnitReport_WellFormed(t *testing.T) {
+	results := []types.CommandResult{
+		{Command: "echo <b>", IsSuccess: false, ExitCode: 1, FailureKind: types.FailureExit, Stdout: "\x1b[31mred\x1b[0m ]]> & \x00done\n"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJUnitReport(&buf, results); err != nil {
+		t.Fatalf("WriteJUnitReport() error = %v", err)
+	}
+
+	var decoded JUnitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Report is not well-formed XML: %v\n%s", err, buf.String())
+	}
+
+	got := decoded.Suites[0].Cases[0].SystemOut.Text
+	if got != "red ]]> & done\n" {
+		t.Errorf("SystemOut = %q, want sanitized output", got)
+	}
+}
+
+func TestBuildJUnitReport_FlakyFailure(t *testing.T) {
+	results := []types.CommandResult{
+		{
+			Command:   "yarn e2e",
+			IsSuccess: true,
+			Attempts: []types.Attempt{
+				{ExitCode: 1, FailureKind: types.FailureExit},
+				{ExitCode: 0},
+			},
+		},
+	}
+
+	report := BuildJUnitReport(results)
+	testCase := report.Suites[0].Cases[0]
+
+	if report.Failures != 0 {
+		t.Errorf("Flaky command should not count as failure, got %d", report.Failures)
+	}
+	if len(testCase.FlakyFailures) != 1 || testCase.FlakyFailures[0].Message != "exit code 1" {
+		t.Errorf("FlakyFailures = %+v, want one exit code 1", testCase.FlakyFailures)
+	}
+}
@@ -0,0 +1,70 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/discovery"
+)
+
+// listGroups задает порядок и заголовки групп в выводе aifr list
+var listGroups = []struct {
+	source discovery.Source
+	title  string
+}{
+	{discovery.SourceTask, "Config tasks"},
+	{discovery.SourcePreset, "Config presets"},
+	{discovery.SourceScript, "package.json scripts"},
+	{discovery.SourceMake, "Make targets"},
+	{discovery.SourceBin, "node_modules/.bin"},
+}
+
+// PrintList выводит найденные задачи, сгруппированные по источнику
+func PrintList(w io.Writer, entries []discovery.Entry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "Nothing to run: no aifr config, package.json scripts, Makefile targets or node_modules/.bin found")
+		return
+	}
+
+	first := true
+	for _, group := range listGroups {
+		groupEntries := []discovery.Entry{}
+		width := 0
+		commandWidth := 0
+		for _, entry := range entries {
+			if entry.Source != group.source {
+				continue
+			}
+			groupEntries = append(groupEntries, entry)
+			width = max(width, len(entry.Name))
+			commandWidth = max(commandWidth, len(entry.Command))
+		}
+
+		if len(groupEntries) == 0 {
+			continue
+		}
+
+		if !first {
+			fmt.Fprintln(w)
+		}
+		first = false
+
+		fmt.Fprintf(w, "%s %s\n", group.title, dim("("+groupEntries[0].File+")"))
+		for _, entry := range groupEntries {
+			if entry.Definition == "" || entry.Definition == entry.Command || entry.Source == discovery.SourceBin {
+				fmt.Fprintf(w, "  %-*s  %s\n", width, entry.Name, entry.Command)
+				continue
+			}
+			fmt.Fprintf(w, "  %-*s  %-*s  %s\n", width, entry.Name, commandWidth, entry.Command, dim("# "+entry.Definition))
+		}
+	}
+}
+
+// WriteListJSON записывает найденные задачи в формате JSON
+func WriteListJSON(w io.Writer, entries []discovery.Entry) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
@@ -0,0 +1,50 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/discovery"
+)
+
+var listEntries = []discovery.Entry{
+	{Name: "lint", Source: discovery.SourceScript, Command: "yarn run lint", Definition: "eslint .", File: "/p/package.json"},
+	{Name: "build", Source: discovery.SourceMake, Command: "make build", File: "/p/Makefile"},
+}
+
+func TestPrintList(t *testing.T) {
+	var buf bytes.Buffer
+	PrintList(&buf, listEntries)
+	output := buf.String()
+
+	for _, want := range []string{"package.json scripts", "yarn run lint", "# eslint .", "Make targets", "make build"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("PrintList() output missing %q:\n%s", want, output)
+		}
+	}
+
+	if strings.Index(output, "package.json scripts") > strings.Index(output, "Make targets") {
+		t.Errorf("Scripts should be listed before Make targets:\n%s", output)
+	}
+}
+
+func TestWriteListJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteListJSON(&buf, listEntries); err != nil {
+		t.Fatalf("WriteListJSON() error = %v", err)
+	}
+
+	var decoded []map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+
+	if len(decoded) != 2 || decoded[0]["source"] != "script" || decoded[0]["command"] != "yarn run lint" {
+		t.Errorf("Unexpected JSON: %s", buf.String())
+	}
+	if _, ok := decoded[1]["definition"]; ok {
+		t.Errorf("Empty definition should be omitted: %s", buf.String())
+	}
+}
@@ -0,0 +1,149 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+// JSONPlan - план запуска для --dry-run --format json
+type JSONPlan struct {
+	Threads  int               `json:"threads"`
+	Commands []JSONPlanCommand `json:"commands"`
+}
+
+// JSONPlanCommand описывает, как будет запущена одна команда
+type JSONPlanCommand struct {
+	Order           int      `json:"order"`
+	Stage           int      `json:"stage"`
+	Name            string   `json:"name,omitempty"`
+	Command         string   `json:"command"`
+	ResolvedCommand string   `json:"resolved_command"`
+	Resolution      string   `json:"resolution"`
+	Executable      string   `json:"executable,omitempty"`
+	Args            []string `json:"argv"`
+	Shell           bool     `json:"shell"`
+	Cwd             string   `json:"cwd"`
+	Env             []string `json:"env"`
+	EnvFiles        []string `json:"env_files,omitempty"`
+	EnvAllow        []string `json:"env_allow,omitempty"`
+	CleanEnv        bool     `json:"clean_env,omitempty"`
+	TimeoutMs       int64    `json:"timeout_ms,omitempty"`
+	Needs           []string `json:"needs,omitempty"`
+	Error           string   `json:"error,omitempty"`
+}
+
+// BuildJSONPlan преобразует план запуска в JSON структуру
+func BuildJSONPlan(plans []types.CommandPlan, flags types.Flags) JSONPlan {
+	report := JSONPlan{
+		Threads:  flags.Threads,
+		Commands: make([]JSONPlanCommand, len(plans)),
+	}
+
+	for i, plan := range plans {
+		report.Commands[i] = JSONPlanCommand{
+			Order:           plan.Order,
+			Stage:           plan.Stage,
+			Name:            plan.Name,
+			Command:         plan.Command,
+			ResolvedCommand: plan.ResolvedCommand,
+			Resolution:      plan.Resolution,
+			Executable:      plan.Executable,
+			Args:            nonNil(plan.Args),
+			Shell:           plan.Shell,
+			Cwd:             plan.Dir,
+			Env:             nonNil(plan.Env),
+			EnvFiles:        plan.EnvFiles,
+			EnvAllow:        plan.EnvAllow,
+			CleanEnv:        plan.CleanEnv,
+			TimeoutMs:       plan.Timeout.Milliseconds(),
+			Needs:           plan.Needs,
+			Error:           plan.Error,
+		}
+	}
+
+	return report
+}
+
+// WritePlanJSON записывает план запуска в формате JSON
+func WritePlanJSON(w io.Writer, plans []types.CommandPlan, flags types.Flags) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	return encoder.Encode(BuildJSONPlan(plans, flags))
+}
+
+// PrintPlan выводит план запуска в текстовом виде
+func PrintPlan(w io.Writer, plans []types.CommandPlan, flags types.Flags) {
+	fmt.Fprintf(w, "\nDry run: %d commands, %d threads (nothing is executed)\n", len(plans), flags.Threads)
+	fmt.Fprintln(w, dim("Commands start in this order as slots free up; a stage waits for its dependencies to succeed."))
+
+	for _, plan := range plans {
+		fmt.Fprintln(w)
+
+		title := cleanCommandName(plan.Command)
+		if plan.Name != "" {
+			title = plan.Name
+		}
+		fmt.Fprintf(w, "%d. %s %s\n", plan.Order, title, dim(fmt.Sprintf("(stage %d)", plan.Stage)))
+
+		printPlanField(w, "command", plan.Command)
+		printPlanField(w, "resolved", plan.ResolvedCommand)
+		printPlanField(w, "via", plan.Resolution)
+		if plan.Error != "" {
+			printPlanField(w, "error", red(plan.Error))
+		} else {
+			printPlanField(w, "executable", plan.Executable)
+		}
+		if len(plan.Args) > 0 {
+			printPlanField(w, "argv", quoteArgs(plan.Args))
+		}
+		printPlanField(w, "shell", strconv.FormatBool(plan.Shell))
+		printPlanField(w, "cwd", plan.Dir)
+		inherited := "inherited"
+		if only := inheritedEnv(plan.CleanEnv, plan.EnvAllow); only != "" {
+			inherited = only + " inherited"
+		}
+		if len(plan.EnvFiles) > 0 {
+			printPlanField(w, "env file", strings.Join(plan.EnvFiles, ", "))
+		}
+		if len(plan.Env) > 0 {
+			printPlanField(w, "env", strings.Join(plan.Env, " ")+" "+dim("(+ "+inherited+")"))
+		} else {
+			printPlanField(w, "env", inherited)
+		}
+		if plan.Timeout > 0 {
+			printPlanField(w, "timeout", plan.Timeout.String())
+		}
+		if len(plan.Needs) > 0 {
+			printPlanField(w, "needs", strings.Join(plan.Needs, ", "))
+		}
+	}
+
+	fmt.Fprintln(w)
+}
+
+func printPlanField(w io.Writer, name, value string) {
+	fmt.Fprintf(w, "   %-11s %s\n", name+":", value)
+}
+
+// quoteArgs выводит argv в виде, однозначно показывающем границы аргументов
+func quoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = strconv.Quote(arg)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,80 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+var testPlans = []types.CommandPlan{
+	{
+		Order:           1,
+		Name:            "lint",
+		Command:         "lint",
+		ResolvedCommand: "yarn run lint",
+		Resolution:      "package.json script from /p/package.json; yarn detected from /p/yarn.lock",
+		Executable:      "/usr/bin/yarn",
+		Args:            []string{"yarn", "run", "lint"},
+		Dir:             "/p",
+		Env:             []string{"CI=1"},
+		Timeout:         time.Minute,
+	},
+	{
+		Order:           2,
+		Stage:           1,
+		Command:         "deploy",
+		ResolvedCommand: "deploy",
+		Args:            []string{"deploy"},
+		Dir:             "/p",
+		Needs:           []string{"lint"},
+		Error:           `exec: "deploy": executable file not found in $PATH`,
+	},
+}
+
+func TestPrintPlan(t *testing.T) {
+	var buf bytes.Buffer
+	PrintPlan(&buf, testPlans, types.Flags{Threads: 2})
+	output := buf.String()
+
+	for _, want := range []string{
+		"Dry run: 2 commands, 2 threads",
+		"1. lint",
+		"yarn detected from /p/yarn.lock",
+		`["yarn", "run", "lint"]`,
+		"CI=1",
+		"2. deploy",
+		"stage 1",
+		"executable file not found",
+		"needs:      lint",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("PrintPlan() output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestWritePlanJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePlanJSON(&buf, testPlans, types.Flags{Threads: 2}); err != nil {
+		t.Fatalf("WritePlanJSON() error = %v", err)
+	}
+
+	var plan JSONPlan
+	if err := json.Unmarshal(buf.Bytes(), &plan); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+
+	if plan.Threads != 2 || len(plan.Commands) != 2 {
+		t.Fatalf("Unexpected plan: %+v", plan)
+	}
+	if plan.Commands[0].TimeoutMs != 60000 || plan.Commands[0].Executable != "/usr/bin/yarn" {
+		t.Errorf("Unexpected first command: %+v", plan.Commands[0])
+	}
+	if !strings.Contains(buf.String(), `"env": []`) {
+		t.Errorf("Empty env should be encoded as an empty array:\n%s", buf.String())
+	}
+}
@@ -2,16 +2,20 @@ package reporter
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/CyberWalrus/ai-friendly-runner/internal/diagnostics"
 	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
 	"github.com/fatih/color"
 )
 
 var (
-	green = color.New(color.FgGreen).SprintFunc()
-	red   = color.New(color.FgRed).SprintFunc()
-	dim   = color.New(color.Faint).SprintFunc()
+	green  = color.New(color.FgGreen).SprintFunc()
+	red    = color.New(color.FgRed).SprintFunc()
+	yellow = color.New(color.FgYellow).SprintFunc()
+	dim    = color.New(color.Faint).SprintFunc()
 )
 
 // cleanCommandName удаляет популярные префиксы запускаторов из имени команды
@@ -27,6 +31,60 @@ func cleanCommandName(command string) string {
 	return command
 }
 
+// describeFailure возвращает короткое описание причины ошибки команды
+func describeFailure(result types.CommandResult) string {
+	description := ""
+
+	switch result.FailureKind {
+	case types.FailureStart:
+		description = "failed to start"
+	case types.FailureExit:
+		description = fmt.Sprintf("exit code %d", result.ExitCode)
+	case types.FailureSignal:
+		description = fmt.Sprintf("killed by %s", result.Signal)
+	case types.FailureTimeout:
+		description = "timed out"
+	case types.FailureCancelled:
+		description = "cancelled"
+	case types.FailureSkipped:
+		description = "skipped"
+	default:
+		if !result.IsSuccess && result.ExitCode > 0 {
+			description = fmt.Sprintf("exit code %d", result.ExitCode)
+		}
+	}
+
+	if len(result.Attempts) > 1 && description != "" {
+		description += fmt.Sprintf(", %d attempts", len(result.Attempts))
+	}
+
+	if result.Reason != "" {
+		if description == "" {
+			return result.Reason
+		}
+		return description + ": " + result.Reason
+	}
+
+	return description
+}
+
+// describeRetry возвращает пометку для команды, прошедшей только после повтора
+func describeRetry(result types.CommandResult) string {
+	if !result.PassedAfterRetry() {
+		return ""
+	}
+	return fmt.Sprintf("passed after retry (attempt %d)", len(result.Attempts))
+}
+
+// describeAttempt возвращает описание одной неудачной попытки
+func describeAttempt(attempt types.Attempt) string {
+	return describeFailure(types.CommandResult{
+		ExitCode:    attempt.ExitCode,
+		Signal:      attempt.Signal,
+		FailureKind: attempt.FailureKind,
+	})
+}
+
 // PrintReport форматирует и выводит отчет о результатах
 func PrintReport(results []types.CommandResult, flags types.Flags) {
 	if len(results) == 0 {
@@ -69,32 +127,42 @@ func PrintReport(results []types.CommandResult, flags types.Flags) {
 		cleanedCommand := cleanCommandName(result.Command)
 
 		if result.IsSuccess {
+			if retry := describeRetry(result); retry != "" {
+				timeStr += fmt.Sprintf(" %s", yellow(retry))
+			}
+
 			if flags.Output == "full" {
 				fmt.Printf("<%s>\n", cleanedCommand)
 				fmt.Printf("%s %s%s\n", status, cleanedCommand, timeStr)
-				if result.Stdout != "" {
-					fmt.Print(result.Stdout)
-				}
-				if result.Stderr != "" {
-					fmt.Print(result.Stderr)
-				}
+				fmt.Print(result.CombinedOutput())
 				fmt.Printf("</%s>\n", cleanedCommand)
 			} else {
 				fmt.Printf("%s %s%s\n", status, cleanedCommand, timeStr)
 			}
 		} else {
-			fmt.Printf("%s %s%s\n", status, cleanedCommand, timeStr)
+			reasonStr := ""
+			if reason := describeFailure(result); reason != "" {
+				reasonStr = fmt.Sprintf(" %s", red(reason))
+			}
+			fmt.Printf("%s %s%s%s\n", status, cleanedCommand, timeStr, reasonStr)
 		}
 	}
 
 	if flags.ShowSummary {
 		totalCount := len(results)
 		summaryText := fmt.Sprintf("Summary: %d/%d passed", passedCount, totalCount)
+		if details := summarizeFailureKinds(results); details != "" {
+			summaryText += fmt.Sprintf(" (%s)", details)
+		}
 
-		if passedCount == totalCount {
-			fmt.Println(green(summaryText))
-		} else {
+		switch {
+		case passedCount != totalCount:
 			fmt.Println(red(summaryText))
+		case hasPassedAfterRetry(results):
+			// Все прошло, но нестабильные команды не должны выглядеть как полностью зеленый запуск
+			fmt.Println(yellow(summaryText))
+		default:
+			fmt.Println(green(summaryText))
 		}
 	}
 
@@ -102,19 +170,35 @@ func PrintReport(results []types.CommandResult, flags types.Flags) {
 		fmt.Println(dim(fmt.Sprintf("Total time: %dms", maxDuration)))
 	}
 
+	if dir := logDir(results); dir != "" {
+		fmt.Println(dim("Logs: " + dir))
+	}
+
 	if len(failedResults) > 0 {
 		fmt.Println()
 
 		for _, result := range failedResults {
 			cleanedCommand := cleanCommandName(result.Command)
 			fmt.Printf("<%s>\n", cleanedCommand)
-			fmt.Printf("%s %s:\n", red("❌"), cleanedCommand)
-			if result.Stderr != "" {
-				fmt.Print(result.Stderr)
+			if reason := describeFailure(result); reason != "" {
+				fmt.Printf("%s %s (%s):\n", red("❌"), cleanedCommand, reason)
+			} else {
+				fmt.Printf("%s %s:\n", red("❌"), cleanedCommand)
+			}
+			if result.Logs != nil {
+				fmt.Println(dim("log: " + result.Logs.Combined))
 			}
-			if result.Stdout != "" {
-				fmt.Print(result.Stdout)
+			for _, line := range describeEnvironment(result) {
+				fmt.Println(dim(line))
 			}
+			if result.OutputError != "" {
+				fmt.Println(yellow("warning: output may be incomplete: " + result.OutputError))
+			}
+			// Диагностики выводятся над сырым выводом, а не вместо него: шаблоны go, rustc и jest совпадают
+			// и с обычными строками логов, и остальные строки вывода не должны теряться
+			output := result.CombinedOutput()
+			printDiagnostics(diagnostics.Parse(output))
+			fmt.Print(output)
 			fmt.Printf("</%s>\n", cleanedCommand)
 			fmt.Println()
 		}
@@ -123,6 +207,127 @@ func PrintReport(results []types.CommandResult, flags types.Flags) {
 	fmt.Println()
 }
 
+// describeEnvironment описывает рабочую директорию и окружение упавшей команды, если они отличаются от aifr;
+// значения из .env файлов не выводятся, только пути к файлам
+func describeEnvironment(result types.CommandResult) []string {
+	lines := []string{}
+	if result.Dir != "" && !isWorkingDir(result.Dir) {
+		lines = append(lines, "cwd: "+result.Dir)
+	}
+	if len(result.EnvFiles) > 0 {
+		lines = append(lines, "env file: "+strings.Join(result.EnvFiles, ", "))
+	}
+	if len(result.Env) > 0 {
+		lines = append(lines, "env: "+strings.Join(result.Env, " "))
+	}
+	if only := inheritedEnv(result.CleanEnv, result.EnvAllow); only != "" {
+		lines = append(lines, "inherited env: "+only)
+	}
+	return lines
+}
+
+// isWorkingDir проверяет, совпадает ли dir с текущей директорией aifr
+func isWorkingDir(dir string) bool {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	cwd, err := os.Getwd()
+	return err == nil && absDir == cwd
+}
+
+// inheritedEnv описывает наследуемое окружение при очищенном окружении: "only PATH, HOME";
+// пустая строка - окружение aifr наследуется целиком
+func inheritedEnv(clean bool, allow []string) string {
+	if !clean && len(allow) == 0 {
+		return ""
+	}
+	return "only " + strings.Join(append([]string{"PATH"}, allow...), ", ")
+}
+
+// logDir возвращает директорию логов запуска по путям логов команд; пустая строка, если логов нет
+func logDir(results []types.CommandResult) string {
+	for _, result := range results {
+		if result.Logs != nil {
+			return filepath.Dir(result.Logs.Combined)
+		}
+	}
+	return ""
+}
+
+// printDiagnostics выводит диагностики по одной строке в формате file:line:col: severity: message [rule]
+func printDiagnostics(found []diagnostics.Diagnostic) {
+	if len(found) == 0 {
+		return
+	}
+
+	errors, warnings := diagnostics.Count(found)
+	fmt.Println(dim(fmt.Sprintf("%s, %s (%s):", pluralize(errors, "error"), pluralize(warnings, "warning"), found[0].Tool)))
+
+	for _, diagnostic := range found {
+		severity := red(diagnostic.Severity)
+		if diagnostic.Severity == diagnostics.SeverityWarning {
+			severity = yellow(diagnostic.Severity)
+		}
+
+		line := fmt.Sprintf("%s: %s: %s", diagnostic.Location(), severity, diagnostic.Message)
+		if diagnostic.Rule != "" {
+			line += " " + dim("["+diagnostic.Rule+"]")
+		}
+		fmt.Println(line)
+	}
+}
+
+// pluralize возвращает "1 error" или "2 errors"
+func pluralize(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, noun)
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
+
+// summarizeFailureKinds возвращает количество команд, завершенных по таймауту, пропущенных или прошедших после повтора
+func summarizeFailureKinds(results []types.CommandResult) string {
+	timedOut := 0
+	skipped := 0
+	retried := 0
+
+	for _, result := range results {
+		if result.PassedAfterRetry() {
+			retried++
+		}
+
+		switch result.FailureKind {
+		case types.FailureTimeout:
+			timedOut++
+		case types.FailureSkipped:
+			skipped++
+		}
+	}
+
+	parts := []string{}
+	if timedOut > 0 {
+		parts = append(parts, fmt.Sprintf("%d timed out", timedOut))
+	}
+	if skipped > 0 {
+		parts = append(parts, fmt.Sprintf("%d skipped", skipped))
+	}
+	if retried > 0 {
+		parts = append(parts, fmt.Sprintf("%d passed after retry", retried))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func hasPassedAfterRetry(results []types.CommandResult) bool {
+	for _, result := range results {
+		if result.PassedAfterRetry() {
+			return true
+		}
+	}
+	return false
+}
+
 // PrintRunning выводит список запускаемых команд
 func PrintRunning(commands []string) {
 	cleanedCommands := make([]string, len(commands))
@@ -401,3 +401,157 @@ func TestAllPassed(t *testing.T) {
 		})
 	}
 }
+
+func TestDescribeFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		result types.CommandResult
+		want   string
+	}{
+		{name: "успех", result: types.CommandResult{IsSuccess: true}, want: ""},
+		{name: "ненулевой код", result: types.CommandResult{FailureKind: types.FailureExit, ExitCode: 2}, want: "exit code 2"},
+		{name: "сигнал", result: types.CommandResult{FailureKind: types.FailureSignal, Signal: "SIGKILL"}, want: "killed by SIGKILL"},
+		{name: "запуск", result: types.CommandResult{FailureKind: types.FailureStart}, want: "failed to start"},
+		{name: "таймаут", result: types.CommandResult{FailureKind: types.FailureTimeout}, want: "timed out"},
+		{name: "отмена", result: types.CommandResult{FailureKind: types.FailureCancelled}, want: "cancelled"},
+		{
+			name:   "после повторов",
+			result: types.CommandResult{FailureKind: types.FailureExit, ExitCode: 1, Attempts: make([]types.Attempt, 3)},
+			want:   "exit code 1, 3 attempts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeFailure(tt.result); got != tt.want {
+				t.Errorf("describeFailure() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintReport_FailureReason(t *testing.T) {
+	results := []types.CommandResult{
+		{Command: "lint", IsSuccess: false, ExitCode: 2, FailureKind: types.FailureExit, Stderr: "2 errors\n"},
+	}
+	flags := types.Flags{Output: "errors"}
+
+	output := captureOutput(func() {
+		PrintReport(results, flags)
+	})
+
+	if !strings.Contains(output, "❌ lint (exit code 2):") {
+		t.Errorf("Failure block should contain exit code, got: %q", output)
+	}
+
+	if !strings.Contains(output, "<lint>") || !strings.Contains(output, "</lint>") {
+		t.Error("Failure block should keep XML tags")
+	}
+}
+
+func TestPrintReport_PassedAfterRetry(t *testing.T) {
+	results := []types.CommandResult{
+		{
+			Command:   "test:e2e",
+			IsSuccess: true,
+			Attempts: []types.Attempt{
+				{ExitCode: 1, FailureKind: types.FailureExit},
+				{ExitCode: 0},
+			},
+		},
+		{Command: "lint", IsSuccess: true},
+	}
+	flags := types.Flags{Output: "errors", ShowSummary: true}
+
+	output := captureOutput(func() {
+		PrintReport(results, flags)
+	})
+
+	if !strings.Contains(output, "test:e2e passed after retry (attempt 2)") {
+		t.Errorf("Flaky command should be marked, got: %q", output)
+	}
+	if !strings.Contains(output, "Summary: 2/2 passed (1 passed after retry)") {
+		t.Errorf("Summary should count flaky commands, got: %q", output)
+	}
+}
+
+func TestPrintReport_Diagnostics(t *testing.T) {
+	tscOutput := "src/app.ts(12,5): error TS2322: Type 'string' is not assignable to type 'number'.\n" +
+		"Found 1 error in src/app.ts:12\n"
+	results := []types.CommandResult{
+		{Command: "tsc --noEmit", ExitCode: 2, FailureKind: types.FailureExit, Stdout: tscOutput},
+	}
+
+	output := captureOutput(func() {
+		PrintReport(results, types.Flags{Output: "errors"})
+	})
+
+	if !strings.Contains(output, "1 error, 0 warnings (tsc):") {
+		t.Errorf("Output should contain diagnostics header, got: %s", output)
+	}
+	if !strings.Contains(output, "src/app.ts:12:5: error: Type 'string' is not assignable to type 'number'. [TS2322]") {
+		t.Errorf("Output should contain compact diagnostic, got: %s", output)
+	}
+	if !strings.Contains(output, "Found 1 error") {
+		t.Errorf("Raw output should follow the diagnostics, got: %s", output)
+	}
+}
+
+func TestPrintReport_DiagnosticsKeepUnmatchedLines(t *testing.T) {
+	results := []types.CommandResult{
+		{
+			Command:     "sh -c build",
+			ExitCode:    1,
+			FailureKind: types.FailureExit,
+			Output: []types.OutputChunk{
+				{Stream: "stdout", Text: "main.go:3:2: undefined: foo\n"},
+				{Stream: "stderr", Text: "FATAL real problem\n"},
+			},
+		},
+	}
+
+	output := captureOutput(func() {
+		PrintReport(results, types.Flags{Output: "errors"})
+	})
+
+	if !strings.Contains(output, "main.go:3:2: error: undefined: foo") {
+		t.Errorf("Output should contain the matched diagnostic, got: %s", output)
+	}
+	if !strings.Contains(output, "FATAL real problem") {
+		t.Errorf("Lines no parser matched should not be dropped, got: %s", output)
+	}
+}
+
+func TestPrintReport_FailedCommandEnvironment(t *testing.T) {
+	results := []types.CommandResult{{
+		Command:     "yarn test",
+		ExitCode:    1,
+		FailureKind: types.FailureExit,
+		Dir:         "/repo/packages/api",
+		Env:         []string{"NODE_ENV"},
+		EnvFiles:    []string{".env.test"},
+		EnvAllow:    []string{"HOME"},
+		Stderr:      "failed\n",
+	}}
+
+	output := captureOutput(func() {
+		PrintReport(results, types.Flags{Output: "errors"})
+	})
+
+	for _, want := range []string{"cwd: /repo/packages/api", "env file: .env.test", "env: NODE_ENV", "inherited env: only PATH, HOME"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Failure block should contain %q, got: %q", want, output)
+		}
+	}
+}
+
+func TestDescribeEnvironment_Default(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lines := describeEnvironment(types.CommandResult{Dir: cwd}); len(lines) != 0 {
+		t.Errorf("Command run in the aifr directory with the inherited env needs no description, got %q", lines)
+	}
+}
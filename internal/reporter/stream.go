@@ -0,0 +1,125 @@
+package reporter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/events"
+	"github.com/fatih/color"
+)
+
+// maxLabelWidth ограничивает ширину подписи команды в режиме --stream
+const maxLabelWidth = 24
+
+// labelColors - цвета подписей команд; красный не используется, он отмечает stderr
+var labelColors = []*color.Color{
+	color.New(color.FgCyan),
+	color.New(color.FgMagenta),
+	color.New(color.FgBlue),
+	color.New(color.FgYellow),
+	color.New(color.FgGreen),
+	color.New(color.FgHiCyan),
+	color.New(color.FgHiMagenta),
+	color.New(color.FgHiBlue),
+	color.New(color.FgHiYellow),
+	color.New(color.FgHiGreen),
+}
+
+// StreamWriter выводит строки команд в режиме --stream. Все строки проходят через одну блокировку
+// и пишутся одним вызовом Write, поэтому строки разных команд и потоков не перемешиваются
+type StreamWriter struct {
+	mu         sync.Mutex
+	out        io.Writer
+	labels     []string
+	started    time.Time
+	timestamps bool
+	partial    map[streamKey]bool // последняя строка потока команды была частичной
+}
+
+// streamKey - поток вывода одной команды
+type streamKey struct {
+	index  int
+	stream string
+}
+
+// NewStreamWriter создает writer для команд с подписями labels; timestamps добавляет время от начала запуска
+func NewStreamWriter(out io.Writer, labels []string, timestamps bool) *StreamWriter {
+	return &StreamWriter{out: out, labels: StreamLabels(labels), started: time.Now(), timestamps: timestamps, partial: map[streamKey]bool{}}
+}
+
+// Handle выводит строку вывода команды: подпись своим цветом, "|" для stdout и красный "!" для stderr
+func (w *StreamWriter) Handle(event events.Event) {
+	if event.Type != events.OutputLine {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Частичная строка уже выведена целиком, пустое завершение ничего не добавляет
+	key := streamKey{index: event.Index, stream: event.Stream}
+	completion := w.partial[key] && !event.Partial && event.Line == ""
+	w.partial[key] = event.Partial
+	if completion {
+		return
+	}
+
+	label := event.Command
+	if event.Index >= 0 && event.Index < len(w.labels) {
+		label = labelColors[event.Index%len(labelColors)].Sprint(w.labels[event.Index])
+	}
+
+	separator := dim("|")
+	if event.Stream == events.StreamStderr {
+		separator = red("!")
+	}
+
+	var b strings.Builder
+	if w.timestamps {
+		b.WriteString(dim(fmt.Sprintf("%9s ", formatOffset(event.Time.Sub(w.started)))))
+	}
+	b.WriteString(label)
+	b.WriteString(" ")
+	b.WriteString(separator)
+	b.WriteString(" ")
+	b.WriteString(event.Line)
+	b.WriteString("\n")
+
+	io.WriteString(w.out, b.String())
+}
+
+// StreamLabels возвращает уникальные подписи одинаковой ширины: длинные подписи обрезаются по символам,
+// а совпавшие после обрезки получают номер команды "#N"
+func StreamLabels(names []string) []string {
+	labels := make([]string, len(names))
+	counts := map[string]int{}
+	for i, name := range names {
+		labels[i] = truncateRunes(cleanCommandName(name), maxLabelWidth)
+		counts[labels[i]]++
+	}
+
+	for i, label := range labels {
+		if counts[label] > 1 {
+			suffix := fmt.Sprintf("#%d", i+1)
+			labels[i] = truncateRunes(label, maxLabelWidth-len(suffix)) + suffix
+		}
+	}
+
+	width := 0
+	for _, label := range labels {
+		width = max(width, len([]rune(label)))
+	}
+	for i, label := range labels {
+		labels[i] = padRunes(label, width)
+	}
+
+	return labels
+}
+
+// formatOffset форматирует время от начала запуска: +1.234s
+func formatOffset(offset time.Duration) string {
+	return fmt.Sprintf("+%.3fs", max(offset, 0).Seconds())
+}
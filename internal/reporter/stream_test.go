@@ -0,0 +1,113 @@
+package reporter
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/events"
+)
+
+func TestStreamLabels(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		want  []string
+	}{
+		{
+			name:  "выравнивание по самой длинной подписи",
+			names: []string{"lint", "yarn typecheck", "api"},
+			want:  []string{"lint     ", "typecheck", "api      "},
+		},
+		{
+			name:  "одинаковые после обрезки",
+			names: []string{"yarn test:integration:users-api", "yarn test:integration:users-web"},
+			want:  []string{"test:integration:user…#1", "test:integration:user…#2"},
+		},
+		{
+			name:  "одинаковые команды",
+			names: []string{"lint", "lint"},
+			want:  []string{"lint#1", "lint#2"},
+		},
+		{
+			name:  "обрезка по символам, а не байтам",
+			names: []string{"echo привет мир, длинная строка"},
+			want:  []string{"echo привет мир, длинна…"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := StreamLabels(tt.names)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("StreamLabels() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamWriter_Handle(t *testing.T) {
+	var out bytes.Buffer
+	writer := NewStreamWriter(&out, []string{"lint", "api=yarn dev"}, true)
+	writer.started = time.Date(2025, 11, 4, 10, 0, 0, 0, time.UTC)
+
+	writer.Handle(events.Event{Type: events.CommandStarted, Index: 0})
+	writer.Handle(events.Event{Type: events.OutputLine, Index: 0, Time: writer.started.Add(1234 * time.Millisecond), Stream: events.StreamStdout, Line: "ok"})
+	writer.Handle(events.Event{Type: events.OutputLine, Index: 1, Time: writer.started.Add(2 * time.Second), Stream: events.StreamStderr, Line: "warning"})
+
+	want := "  +1.234s lint         | ok\n  +2.000s api=yarn dev ! warning\n"
+	if out.String() != want {
+		t.Errorf("Output =\n%q\nwant\n%q", out.String(), want)
+	}
+}
+
+func TestStreamWriter_ConcurrentLines(t *testing.T) {
+	var out bytes.Buffer
+	writer := NewStreamWriter(&out, []string{"a", "b", "c", "d"}, false)
+
+	var wg sync.WaitGroup
+	for index := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				writer.Handle(events.Event{Type: events.OutputLine, Index: index, Stream: events.StreamStdout, Line: fmt.Sprintf("line %d of %d", i, index)})
+			}
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 800 {
+		t.Fatalf("Expected 800 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		var label string
+		var i, index int
+		if _, err := fmt.Sscanf(line, "%s | line %d of %d", &label, &i, &index); err != nil || label != string(rune('a'+index)) {
+			t.Fatalf("Interleaved or malformed line %q", line)
+		}
+	}
+}
+
+func TestStreamWriter_PartialLines(t *testing.T) {
+	var out bytes.Buffer
+	writer := NewStreamWriter(&out, []string{"deploy"}, false)
+
+	for _, event := range []events.Event{
+		{Type: events.OutputLine, Index: 0, Stream: events.StreamStdout, Line: "Continue? ", Partial: true},
+		{Type: events.OutputLine, Index: 0, Stream: events.StreamStdout, Line: ""},
+		{Type: events.OutputLine, Index: 0, Stream: events.StreamStdout, Line: ""},
+	} {
+		writer.Handle(event)
+	}
+
+	// Пустое завершение частичной строки пропускается, а настоящая пустая строка выводится
+	want := "deploy | Continue? \ndeploy | \n"
+	if out.String() != want {
+		t.Errorf("Output = %q, want %q", out.String(), want)
+	}
+}
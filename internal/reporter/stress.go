@@ -0,0 +1,360 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+// StressStats - статистика одной команды по всем повторам --repeat
+type StressStats struct {
+	Command          string
+	Name             string
+	Runs             int
+	Passed           int
+	Failed           int
+	Skipped          int // повторы, в которых команда не запускалась
+	PassedAfterRetry int
+	Min              time.Duration // длительности считаются только по запущенным повторам
+	Median           time.Duration
+	Max              time.Duration
+	Failures         []StressFailure // различные ошибки в порядке первого появления
+}
+
+// StressFailure - одна уникальная ошибка команды и повторы, в которых она встретилась
+type StressFailure struct {
+	Reason string
+	Output string
+	Runs   []int // номера повторов, начиная с 1
+}
+
+// FailureRate возвращает долю неуспешных среди запущенных повторов
+func (s StressStats) FailureRate() float64 {
+	executed := s.Passed + s.Failed
+	if executed == 0 {
+		return 0
+	}
+	return float64(s.Failed) / float64(executed)
+}
+
+// Status возвращает итог команды: passed, flaky, failed или skipped
+func (s StressStats) Status() string {
+	switch {
+	case s.Passed+s.Failed == 0:
+		return "skipped"
+	case s.Failed == 0:
+		return "passed"
+	case s.Passed == 0:
+		return "failed"
+	default:
+		return "flaky"
+	}
+}
+
+// BuildStressStats собирает статистику по командам из результатов повторов runs[i][j]
+func BuildStressStats(specs []types.CommandSpec, runs [][]types.CommandResult) []StressStats {
+	stats := make([]StressStats, len(specs))
+
+	for j, commandSpec := range specs {
+		commandStats := StressStats{
+			Command: commandSpec.Command,
+			Name:    commandSpec.Name,
+			Runs:    len(runs),
+		}
+		durations := []time.Duration{}
+		failureIndex := map[string]int{}
+
+		for i, results := range runs {
+			result := results[j]
+
+			switch resultStatus(result) {
+			case "passed":
+				commandStats.Passed++
+				if result.PassedAfterRetry() {
+					commandStats.PassedAfterRetry++
+				}
+			case "skipped":
+				commandStats.Skipped++
+				continue
+			default:
+				commandStats.Failed++
+
+				reason := describeFailure(result)
+				output := strings.TrimSpace(result.CombinedOutput())
+				key := reason + "\x00" + output
+				index, exists := failureIndex[key]
+				if !exists {
+					index = len(commandStats.Failures)
+					failureIndex[key] = index
+					commandStats.Failures = append(commandStats.Failures, StressFailure{Reason: reason, Output: output})
+				}
+				commandStats.Failures[index].Runs = append(commandStats.Failures[index].Runs, i+1)
+			}
+
+			durations = append(durations, result.Duration)
+		}
+
+		if len(durations) > 0 {
+			sort.Slice(durations, func(a, b int) bool { return durations[a] < durations[b] })
+			commandStats.Min = durations[0]
+			commandStats.Max = durations[len(durations)-1]
+			commandStats.Median = median(durations)
+		}
+
+		stats[j] = commandStats
+	}
+
+	return stats
+}
+
+// median возвращает медиану отсортированных длительностей
+func median(sorted []time.Duration) time.Duration {
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[middle]
+	}
+	return (sorted[middle-1] + sorted[middle]) / 2
+}
+
+// stressTitle возвращает имя команды для отчета
+func stressTitle(stats StressStats) string {
+	if stats.Name != "" {
+		return stats.Name
+	}
+	return cleanCommandName(stats.Command)
+}
+
+// PrintRepeating выводит список команд, запускаемых в режиме --repeat
+func PrintRepeating(commands []string, times int) {
+	cleanedCommands := make([]string, len(commands))
+	for i, cmd := range commands {
+		cleanedCommands[i] = cleanCommandName(cmd)
+	}
+	fmt.Printf("\nRepeating %d times: %s\n", times, strings.Join(cleanedCommands, ", "))
+}
+
+// PrintStressReport выводит отчет --repeat в текстовом виде
+func PrintStressReport(w io.Writer, stats []StressStats, flags types.Flags) {
+	if len(stats) == 0 || flags.Output == "none" {
+		return
+	}
+
+	fmt.Fprintln(w)
+
+	width := 0
+	for _, commandStats := range stats {
+		width = max(width, len(stressTitle(commandStats)))
+	}
+
+	stable := 0
+	for _, commandStats := range stats {
+		status := green("✅")
+		switch commandStats.Status() {
+		case "passed":
+			stable++
+		case "flaky":
+			status = yellow("⚠️ ")
+		default:
+			status = red("❌")
+		}
+
+		line := fmt.Sprintf("%s %-*s  %d/%d passed", status, width, stressTitle(commandStats), commandStats.Passed, commandStats.Runs)
+		if commandStats.Failed > 0 {
+			line += " " + red(fmt.Sprintf("(%.1f%% failed)", commandStats.FailureRate()*100))
+		}
+		if commandStats.Skipped > 0 {
+			line += " " + dim(fmt.Sprintf("(%d not run)", commandStats.Skipped))
+		}
+		if commandStats.PassedAfterRetry > 0 {
+			line += " " + yellow(fmt.Sprintf("(%d passed after retry)", commandStats.PassedAfterRetry))
+		}
+		if flags.ShowTime && commandStats.Passed+commandStats.Failed > 0 {
+			line += " " + dim(fmt.Sprintf("min %dms, median %dms, max %dms",
+				commandStats.Min.Milliseconds(), commandStats.Median.Milliseconds(), commandStats.Max.Milliseconds()))
+		}
+		fmt.Fprintln(w, line)
+	}
+
+	if flags.ShowSummary {
+		summaryText := fmt.Sprintf("Summary: %d/%d commands passed all %d runs", stable, len(stats), stats[0].Runs)
+		if stable == len(stats) {
+			fmt.Fprintln(w, green(summaryText))
+		} else {
+			fmt.Fprintln(w, red(summaryText))
+		}
+	}
+
+	for _, commandStats := range stats {
+		if len(commandStats.Failures) == 0 {
+			continue
+		}
+
+		title := stressTitle(commandStats)
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "<%s>\n", title)
+		fmt.Fprintf(w, "%s %s: %s in %s\n", red("❌"), title, pluralize(len(commandStats.Failures), "distinct failure"), pluralize(commandStats.Failed, "run"))
+		for _, failure := range commandStats.Failures {
+			runs := "runs "
+			if len(failure.Runs) == 1 {
+				runs = "run "
+			}
+			fmt.Fprintf(w, "%s %s\n", red(fmt.Sprintf("%d× %s", len(failure.Runs), failure.Reason)), dim("("+runs+joinInts(failure.Runs)+")"))
+			if failure.Output != "" {
+				fmt.Fprintln(w, failure.Output)
+			}
+		}
+		fmt.Fprintf(w, "</%s>\n", title)
+	}
+
+	fmt.Fprintln(w)
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = strconv.Itoa(value)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// JSONStressReport - отчет --repeat в формате JSON
+type JSONStressReport struct {
+	Run      JSONRunInfo         `json:"run"`
+	Repeat   int                 `json:"repeat"`
+	Summary  JSONStressSummary   `json:"summary"`
+	Commands []JSONStressCommand `json:"commands"`
+}
+
+// JSONStressSummary - итог повторов по командам
+type JSONStressSummary struct {
+	Success bool `json:"success"`
+	Total   int  `json:"total"`
+	Passed  int  `json:"passed"`
+	Flaky   int  `json:"flaky"`
+	Failed  int  `json:"failed"`
+	Skipped int  `json:"skipped"`
+}
+
+// JSONStressCommand - статистика одной команды по повторам
+type JSONStressCommand struct {
+	Command          string              `json:"command"`
+	Name             string              `json:"name,omitempty"`
+	Status           string              `json:"status"`
+	Runs             int                 `json:"runs"`
+	Passed           int                 `json:"passed"`
+	Failed           int                 `json:"failed"`
+	Skipped          int                 `json:"skipped"`
+	PassedAfterRetry int                 `json:"passed_after_retry,omitempty"`
+	FailureRate      float64             `json:"failure_rate"`
+	MinMs            int64               `json:"min_ms"`
+	MedianMs         int64               `json:"median_ms"`
+	MaxMs            int64               `json:"max_ms"`
+	Failures         []JSONStressFailure `json:"failures"`
+}
+
+// JSONStressFailure - уникальная ошибка команды
+type JSONStressFailure struct {
+	Reason string `json:"reason"`
+	Output string `json:"output"`
+	Count  int    `json:"count"`
+	Runs   []int  `json:"runs"`
+}
+
+// BuildJSONStressReport преобразует статистику повторов в JSON структуру
+func BuildJSONStressReport(stats []StressStats, info types.RunInfo) JSONStressReport {
+	report := JSONStressReport{
+		Run: JSONRunInfo{
+			Version:    info.Version,
+			Cwd:        info.Cwd,
+			Threads:    info.Threads,
+			StartedAt:  info.StartedAt,
+			FinishedAt: info.FinishedAt,
+			DurationMs: info.FinishedAt.Sub(info.StartedAt).Milliseconds(),
+			LogDir:     info.LogDir,
+		},
+		Summary:  JSONStressSummary{Total: len(stats)},
+		Commands: make([]JSONStressCommand, 0, len(stats)),
+	}
+
+	for _, commandStats := range stats {
+		report.Repeat = commandStats.Runs
+
+		status := commandStats.Status()
+		switch status {
+		case "passed":
+			report.Summary.Passed++
+		case "flaky":
+			report.Summary.Flaky++
+		case "failed":
+			report.Summary.Failed++
+		default:
+			report.Summary.Skipped++
+		}
+
+		failures := make([]JSONStressFailure, len(commandStats.Failures))
+		for i, failure := range commandStats.Failures {
+			failures[i] = JSONStressFailure{
+				Reason: failure.Reason,
+				Output: failure.Output,
+				Count:  len(failure.Runs),
+				Runs:   failure.Runs,
+			}
+		}
+
+		report.Commands = append(report.Commands, JSONStressCommand{
+			Command:          commandStats.Command,
+			Name:             commandStats.Name,
+			Status:           status,
+			Runs:             commandStats.Runs,
+			Passed:           commandStats.Passed,
+			Failed:           commandStats.Failed,
+			Skipped:          commandStats.Skipped,
+			PassedAfterRetry: commandStats.PassedAfterRetry,
+			FailureRate:      commandStats.FailureRate(),
+			MinMs:            commandStats.Min.Milliseconds(),
+			MedianMs:         commandStats.Median.Milliseconds(),
+			MaxMs:            commandStats.Max.Milliseconds(),
+			Failures:         failures,
+		})
+	}
+
+	report.Summary.Success = report.Summary.Passed == report.Summary.Total
+
+	return report
+}
+
+// WriteStressJSON записывает отчет --repeat в формате JSON
+func WriteStressJSON(w io.Writer, stats []StressStats, info types.RunInfo) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	return encoder.Encode(BuildJSONStressReport(stats, info))
+}
+
+// WriteStressJSONFile сохраняет отчет --repeat в файл, создавая недостающие директории
+func WriteStressJSONFile(path string, stats []StressStats, info types.RunInfo) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+
+	if err := WriteStressJSON(file, stats, info); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write report file: %w", err)
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,164 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+func stressRuns() ([]types.CommandSpec, [][]types.CommandResult) {
+	specs := []types.CommandSpec{{Command: "yarn lint"}, {Name: "e2e", Command: "yarn test:e2e"}}
+
+	passed := func(command string, ms int) types.CommandResult {
+		return types.CommandResult{Command: command, IsSuccess: true, Duration: time.Duration(ms) * time.Millisecond}
+	}
+	failed := func(command string, ms int, stderr string) types.CommandResult {
+		return types.CommandResult{
+			Command:     command,
+			ExitCode:    1,
+			FailureKind: types.FailureExit,
+			Stderr:      stderr,
+			Duration:    time.Duration(ms) * time.Millisecond,
+		}
+	}
+
+	runs := [][]types.CommandResult{
+		{passed("yarn lint", 100), failed("yarn test:e2e", 300, "timeout waiting for page\n")},
+		{passed("yarn lint", 120), passed("yarn test:e2e", 200)},
+		{passed("yarn lint", 110), failed("yarn test:e2e", 400, "connection refused\n")},
+		{passed("yarn lint", 130), failed("yarn test:e2e", 500, "timeout waiting for page\n")},
+	}
+
+	return specs, runs
+}
+
+func TestBuildStressStats(t *testing.T) {
+	specs, runs := stressRuns()
+
+	stats := BuildStressStats(specs, runs)
+
+	if len(stats) != 2 {
+		t.Fatalf("Expected stats for 2 commands, got %d", len(stats))
+	}
+
+	lint := stats[0]
+	if lint.Status() != "passed" || lint.Passed != 4 || lint.Failed != 0 || lint.FailureRate() != 0 {
+		t.Errorf("Unexpected lint stats: %+v", lint)
+	}
+	if lint.Min != 100*time.Millisecond || lint.Median != 115*time.Millisecond || lint.Max != 130*time.Millisecond {
+		t.Errorf("Unexpected lint durations: min %v, median %v, max %v", lint.Min, lint.Median, lint.Max)
+	}
+
+	e2e := stats[1]
+	if e2e.Status() != "flaky" || e2e.Passed != 1 || e2e.Failed != 3 || e2e.FailureRate() != 0.75 {
+		t.Errorf("Unexpected e2e stats: %+v", e2e)
+	}
+
+	wantFailures := []StressFailure{
+		{Reason: "exit code 1", Output: "timeout waiting for page", Runs: []int{1, 4}},
+		{Reason: "exit code 1", Output: "connection refused", Runs: []int{3}},
+	}
+	if !reflect.DeepEqual(e2e.Failures, wantFailures) {
+		t.Errorf("Failures = %+v, want %+v", e2e.Failures, wantFailures)
+	}
+}
+
+func TestBuildStressStats_SkippedRuns(t *testing.T) {
+	specs := []types.CommandSpec{{Command: "test"}}
+	runs := [][]types.CommandResult{
+		{{Command: "test", ExitCode: 2, FailureKind: types.FailureExit, Duration: 50 * time.Millisecond}},
+		{{Command: "test", ExitCode: -1, FailureKind: types.FailureSkipped, Reason: "fail-fast"}},
+	}
+
+	stats := BuildStressStats(specs, runs)[0]
+
+	if stats.Status() != "failed" || stats.Failed != 1 || stats.Skipped != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if stats.FailureRate() != 1 {
+		t.Errorf("Skipped runs should not affect failure rate, got %v", stats.FailureRate())
+	}
+	if stats.Min != 50*time.Millisecond || stats.Max != 50*time.Millisecond {
+		t.Errorf("Skipped runs should not affect durations, got min %v, max %v", stats.Min, stats.Max)
+	}
+}
+
+func TestPrintStressReport(t *testing.T) {
+	specs, runs := stressRuns()
+	flags := types.Flags{Output: "errors", ShowSummary: true, ShowTime: true}
+
+	var buf bytes.Buffer
+	PrintStressReport(&buf, BuildStressStats(specs, runs), flags)
+	output := buf.String()
+
+	expected := []string{
+		"lint  4/4 passed",
+		"e2e   1/4 passed",
+		"(75.0% failed)",
+		"min 200ms, median 350ms, max 500ms",
+		"Summary: 1/2 commands passed all 4 runs",
+		"e2e: 2 distinct failures in 3 runs",
+		"2× exit code 1",
+		"(runs 1, 4)",
+		"(run 3)",
+		"connection refused",
+	}
+	for _, want := range expected {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+
+	if strings.Count(output, "timeout waiting for page") != 1 {
+		t.Errorf("Identical failures should be printed once, got:\n%s", output)
+	}
+}
+
+func TestPrintStressReport_SingleFailedRun(t *testing.T) {
+	specs, runs := stressRuns()
+	runs = runs[:2]
+
+	var buf bytes.Buffer
+	PrintStressReport(&buf, BuildStressStats(specs, runs), types.Flags{Output: "errors"})
+
+	if !strings.Contains(buf.String(), "e2e: 1 distinct failure in 1 run\n") || !strings.Contains(buf.String(), "(run 1)") {
+		t.Errorf("Single failed run should use singular nouns, got:\n%s", buf.String())
+	}
+}
+
+func TestBuildJSONStressReport(t *testing.T) {
+	specs, runs := stressRuns()
+
+	var buf bytes.Buffer
+	if err := WriteStressJSON(&buf, BuildStressStats(specs, runs), types.RunInfo{Threads: 2}); err != nil {
+		t.Fatalf("WriteStressJSON failed: %v", err)
+	}
+
+	var report JSONStressReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+
+	if report.Repeat != 4 {
+		t.Errorf("Repeat = %d, want 4", report.Repeat)
+	}
+	if report.Summary.Success || report.Summary.Passed != 1 || report.Summary.Flaky != 1 {
+		t.Errorf("Unexpected summary: %+v", report.Summary)
+	}
+
+	e2e := report.Commands[1]
+	if e2e.Status != "flaky" || e2e.FailureRate != 0.75 || e2e.MedianMs != 350 {
+		t.Errorf("Unexpected e2e command: %+v", e2e)
+	}
+	if len(e2e.Failures) != 2 || e2e.Failures[0].Count != 2 {
+		t.Errorf("Unexpected failures: %+v", e2e.Failures)
+	}
+	if report.Commands[0].Failures == nil {
+		t.Error("failures should be an empty array, not null")
+	}
+}
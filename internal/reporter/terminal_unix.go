@@ -0,0 +1,18 @@
+//go:build !windows
+
+package reporter
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminalSize возвращает ширину и высоту терминала в символах; 0, 0 - если file не терминал
+func terminalSize(file *os.File) (int, int) {
+	size, err := unix.IoctlGetWinsize(int(file.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0, 0
+	}
+	return int(size.Col), int(size.Row)
+}
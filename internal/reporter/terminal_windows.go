@@ -0,0 +1,18 @@
+//go:build windows
+
+package reporter
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// terminalSize возвращает ширину и высоту консоли в символах; 0, 0 - если file не консоль
+func terminalSize(file *os.File) (int, int) {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(file.Fd()), &info); err != nil {
+		return 0, 0
+	}
+	return int(info.Window.Right-info.Window.Left) + 1, int(info.Window.Bottom-info.Window.Top) + 1
+}
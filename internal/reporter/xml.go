@@ -0,0 +1,148 @@
+package reporter
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/diagnostics"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+// XMLReport - корневой элемент well-formed XML отчета
+type XMLReport struct {
+	XMLName  xml.Name     `xml:"aifr"`
+	Status   string       `xml:"status,attr"`
+	Total    int          `xml:"total,attr"`
+	Passed   int          `xml:"passed,attr"`
+	Failed   int          `xml:"failed,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Commands []XMLCommand `xml:"command"`
+}
+
+// XMLCommand описывает одну команду; вывод передается в CDATA
+type XMLCommand struct {
+	Name        string          `xml:"name,attr"`
+	Command     string          `xml:"command,attr"`
+	Status      string          `xml:"status,attr"`
+	Exit        int             `xml:"exit,attr"`
+	DurationMs  int64           `xml:"duration_ms,attr"`
+	Signal      string          `xml:"signal,attr,omitempty"`
+	Failure     string          `xml:"failure,attr,omitempty"`
+	Reason      string          `xml:"reason,attr,omitempty"`
+	Attempts    int             `xml:"attempts,attr,omitempty"`
+	Retried     bool            `xml:"passed_after_retry,attr,omitempty"`
+	Omitted     int             `xml:"omitted_lines,attr,omitempty"`
+	FullLog     string          `xml:"full_log,attr,omitempty"`
+	Log         string          `xml:"log,attr,omitempty"`
+	OutputError string          `xml:"output_error,attr,omitempty"`
+	Diagnostics []XMLDiagnostic `xml:"diagnostic"`
+	Stderr      *XMLOutput      `xml:"stderr,omitempty"`
+	Stdout      *XMLOutput      `xml:"stdout,omitempty"`
+}
+
+// XMLDiagnostic - ошибка или предупреждение, разобранные из вывода команды
+type XMLDiagnostic struct {
+	Tool     string `xml:"tool,attr"`
+	File     string `xml:"file,attr"`
+	Line     int    `xml:"line,attr,omitempty"`
+	Column   int    `xml:"column,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Rule     string `xml:"rule,attr,omitempty"`
+	Message  string `xml:",chardata"`
+}
+
+// XMLOutput - содержимое stdout и stderr
+type XMLOutput struct {
+	Text string `xml:",cdata"`
+}
+
+func newXMLOutput(text string) *XMLOutput {
+	if text == "" {
+		return nil
+	}
+	return &XMLOutput{Text: sanitizeXMLText(text)}
+}
+
+// BuildXMLReport собирает XML отчет; вывод включается в зависимости от flags.Output так же, как в текстовом отчете
+func BuildXMLReport(results []types.CommandResult, flags types.Flags) XMLReport {
+	summary := buildSummary(results)
+
+	report := XMLReport{
+		Status:   "passed",
+		Total:    summary.Total,
+		Passed:   summary.Passed,
+		Failed:   summary.Failed,
+		Skipped:  summary.Skipped,
+		Commands: make([]XMLCommand, 0, len(results)),
+	}
+	if !summary.Success {
+		report.Status = "failed"
+	}
+
+	for _, result := range results {
+		command := XMLCommand{
+			Name:        cleanCommandName(result.Command),
+			Command:     sanitizeXMLText(result.Command),
+			Status:      resultStatus(result),
+			Exit:        result.ExitCode,
+			DurationMs:  result.Duration.Milliseconds(),
+			Signal:      result.Signal,
+			Failure:     string(result.FailureKind),
+			Reason:      sanitizeXMLText(result.Reason),
+			Attempts:    len(result.Attempts),
+			Retried:     result.PassedAfterRetry(),
+			Omitted:     result.OmittedLines,
+			FullLog:     sanitizeXMLText(result.FullLog),
+			OutputError: sanitizeXMLText(result.OutputError),
+		}
+		command.Name = sanitizeXMLText(command.Name)
+		if result.Logs != nil {
+			command.Log = sanitizeXMLText(result.Logs.Combined)
+		}
+
+		includeOutput := flags.Output == "full" || (flags.Output != "none" && !result.IsSuccess)
+		if includeOutput {
+			command.Stderr = newXMLOutput(result.Stderr)
+			command.Stdout = newXMLOutput(result.Stdout)
+		}
+		if includeOutput && !result.IsSuccess {
+			for _, diagnostic := range diagnostics.Parse(result.CombinedOutput()) {
+				command.Diagnostics = append(command.Diagnostics, XMLDiagnostic{
+					Tool:     diagnostic.Tool,
+					File:     sanitizeXMLText(diagnostic.File),
+					Line:     diagnostic.Line,
+					Column:   diagnostic.Column,
+					Severity: diagnostic.Severity,
+					Rule:     sanitizeXMLText(diagnostic.Rule),
+					Message:  sanitizeXMLText(diagnostic.Message),
+				})
+			}
+		}
+
+		report.Commands = append(report.Commands, command)
+	}
+
+	return report
+}
+
+// WriteXMLReport пишет XML отчет в writer
+func WriteXMLReport(w io.Writer, results []types.CommandResult, flags types.Flags) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(BuildXMLReport(results, flags)); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// PrintXMLReport выводит XML отчет в stdout
+func PrintXMLReport(results []types.CommandResult, flags types.Flags) error {
+	return WriteXMLReport(os.Stdout, results, flags)
+}
@@ -0,0 +1,84 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+func TestWriteXMLReport_WellFormed(t *testing.T) {
+	results := []types.CommandResult{
+		{Command: "go test ./...", IsSuccess: true, Duration: 10 * time.Millisecond, Stdout: "ok\n"},
+		{Command: "yarn lint", IsSuccess: false, ExitCode: 1, FailureKind: types.FailureExit, Duration: 203 * time.Millisecond, Stderr: "</lint> & <b> ]]>\n"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteXMLReport(&buf, results, types.Flags{Output: "errors"}); err != nil {
+		t.Fatalf("WriteXMLReport() error = %v", err)
+	}
+
+	var decoded XMLReport
+	if err := xml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Report is not well-formed XML: %v\n%s", err, buf.String())
+	}
+
+	if decoded.Status != "failed" || decoded.Total != 2 || decoded.Failed != 1 {
+		t.Errorf("Unexpected root attributes: %+v", decoded)
+	}
+
+	lint := decoded.Commands[1]
+	if lint.Name != "lint" || lint.Exit != 1 || lint.DurationMs != 203 || lint.Status != "failed" {
+		t.Errorf("Unexpected command attributes: %+v", lint)
+	}
+	if lint.Stderr == nil || lint.Stderr.Text != "</lint> & <b> ]]>\n" {
+		t.Errorf("Stderr should survive XML round trip, got %+v", lint.Stderr)
+	}
+
+	if decoded.Commands[0].Stdout != nil {
+		t.Error("Successful command output should be omitted in errors mode")
+	}
+
+	if !strings.Contains(buf.String(), `<command name="go test ./..."`) {
+		t.Error("Command names with spaces should be attributes, not tag names")
+	}
+}
+
+func TestBuildXMLReport_OutputModes(t *testing.T) {
+	results := []types.CommandResult{
+		{Command: "lint", IsSuccess: true, Stdout: "ok\n"},
+		{Command: "test", IsSuccess: false, Stderr: "fail\n"},
+	}
+
+	full := BuildXMLReport(results, types.Flags{Output: "full"})
+	if full.Commands[0].Stdout == nil || full.Commands[1].Stderr == nil {
+		t.Error("Full mode should include output of every command")
+	}
+
+	none := BuildXMLReport(results, types.Flags{Output: "none"})
+	if none.Commands[1].Stderr != nil {
+		t.Error("None mode should omit output")
+	}
+}
+
+func TestWriteXMLReport_SanitizesReason(t *testing.T) {
+	results := []types.CommandResult{
+		{Command: "build", IsSuccess: false, ExitCode: -1, FailureKind: types.FailureStart, Reason: "exec: \x1b[31mbad\x00 binary"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteXMLReport(&buf, results, types.Flags{Output: "errors"}); err != nil {
+		t.Fatalf("WriteXMLReport() error = %v", err)
+	}
+
+	var decoded XMLReport
+	if err := xml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Report with control characters in reason is not well-formed XML: %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(decoded.Commands[0].Reason, "bad") {
+		t.Errorf("Reason = %q, want sanitized text", decoded.Commands[0].Reason)
+	}
+}
@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+// abortError - причина отмены запуска после превышения лимита ошибок
+type abortError struct {
+	reason string
+}
+
+func (e *abortError) Error() string {
+	return e.reason
+}
+
+// abortReason возвращает причину остановки запуска по --fail-fast/--max-failures или пустую строку
+func abortReason(ctx context.Context) string {
+	var abortErr *abortError
+	if errors.As(context.Cause(ctx), &abortErr) {
+		return abortErr.reason
+	}
+	return ""
+}
+
+// failureCounter считает ошибки команд и отменяет запуск при достижении лимита
+type failureCounter struct {
+	mu    sync.Mutex
+	limit int
+	count int
+	abort context.CancelCauseFunc
+}
+
+func newFailureCounter(limit int, abort context.CancelCauseFunc) *failureCounter {
+	return &failureCounter{limit: limit, abort: abort}
+}
+
+// record учитывает результат команды; отмененные и пропущенные команды ошибками не считаются
+func (c *failureCounter) record(cmdSpec types.CommandSpec, result types.CommandResult) {
+	if c.limit <= 0 || result.IsSuccess {
+		return
+	}
+	if result.FailureKind == types.FailureCancelled || result.FailureKind == types.FailureSkipped {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.count++
+	if c.count != c.limit {
+		return
+	}
+
+	reason := fmt.Sprintf("run aborted after %q failed", cmdSpec.Ref())
+	if c.limit > 1 {
+		reason = fmt.Sprintf("run aborted after %d failures, last %q", c.count, cmdSpec.Ref())
+	}
+	c.abort(&abortError{reason: reason})
+}
@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+// ValidateDependencies проверяет, что все зависимости существуют и не образуют цикл
+func ValidateDependencies(specs []types.CommandSpec) error {
+	_, err := resolveDependencies(specs)
+	return err
+}
+
+// resolveDependencies превращает имена зависимостей в индексы команд и ищет циклы
+func resolveDependencies(specs []types.CommandSpec) ([][]int, error) {
+	indexByRef := make(map[string]int, len(specs))
+	duplicates := map[string]bool{}
+	for i, spec := range specs {
+		ref := spec.Ref()
+		if _, exists := indexByRef[ref]; exists {
+			duplicates[ref] = true
+			continue
+		}
+		indexByRef[ref] = i
+	}
+
+	graph := make([][]int, len(specs))
+	for i, spec := range specs {
+		for _, need := range spec.Needs {
+			if duplicates[need] {
+				return nil, fmt.Errorf("ambiguous dependency %q of %q: give the commands distinct names", need, spec.Ref())
+			}
+			index, ok := indexByRef[need]
+			if !ok {
+				return nil, fmt.Errorf("unknown dependency %q of %q", need, spec.Ref())
+			}
+			if index == i {
+				return nil, fmt.Errorf("command %q depends on itself", need)
+			}
+			graph[i] = append(graph[i], index)
+		}
+	}
+
+	if cycle := findCycle(graph); cycle != nil {
+		names := make([]string, len(cycle))
+		for i, index := range cycle {
+			names[i] = specs[index].Ref()
+		}
+		return nil, fmt.Errorf("dependency cycle: %s", strings.Join(names, " -> "))
+	}
+
+	return graph, nil
+}
+
+// findCycle возвращает цикл в графе зависимостей в порядке выполнения или nil
+func findCycle(graph [][]int) []int {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make([]int, len(graph))
+	stack := []int{}
+
+	var visit func(node int) []int
+	visit = func(node int) []int {
+		state[node] = visiting
+		stack = append(stack, node)
+
+		for _, dep := range graph[node] {
+			switch state[dep] {
+			case visiting:
+				for i, n := range stack {
+					if n == dep {
+						cycle := append([]int{}, stack[i:]...)
+						cycle = append(cycle, dep)
+						reverse(cycle)
+						return cycle
+					}
+				}
+			case unvisited:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[node] = visited
+		return nil
+	}
+
+	for node := range graph {
+		if state[node] == unvisited {
+			if cycle := visit(node); cycle != nil {
+				return cycle
+			}
+		}
+	}
+
+	return nil
+}
+
+func reverse(values []int) {
+	for i, j := 0, len(values)-1; i < j; i, j = i+1, j-1 {
+		values[i], values[j] = values[j], values[i]
+	}
+}
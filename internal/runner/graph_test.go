@@ -0,0 +1,132 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+func TestValidateDependencies(t *testing.T) {
+	tests := []struct {
+		name    string
+		specs   []types.CommandSpec
+		wantErr string
+	}{
+		{
+			name: "без зависимостей",
+			specs: []types.CommandSpec{
+				{Command: "lint"},
+				{Command: "test"},
+			},
+		},
+		{
+			name: "цепочка",
+			specs: []types.CommandSpec{
+				{Command: "build"},
+				{Command: "test", Needs: []string{"build"}},
+				{Command: "e2e", Needs: []string{"test"}},
+			},
+		},
+		{
+			name: "цикл",
+			specs: []types.CommandSpec{
+				{Command: "a", Needs: []string{"c"}},
+				{Command: "b", Needs: []string{"a"}},
+				{Command: "c", Needs: []string{"b"}},
+			},
+			wantErr: "dependency cycle",
+		},
+		{
+			name: "зависимость от себя",
+			specs: []types.CommandSpec{
+				{Command: "a", Needs: []string{"a"}},
+			},
+			wantErr: "depends on itself",
+		},
+		{
+			name: "неизвестная зависимость",
+			specs: []types.CommandSpec{
+				{Command: "a", Needs: []string{"missing"}},
+			},
+			wantErr: "unknown dependency",
+		},
+		{
+			name: "зависимость от повторяющейся команды",
+			specs: []types.CommandSpec{
+				{Command: "build"},
+				{Command: "build"},
+				{Command: "test", Needs: []string{"build"}},
+			},
+			wantErr: "ambiguous dependency",
+		},
+		{
+			name: "повторяющаяся команда без зависимых",
+			specs: []types.CommandSpec{
+				{Command: "lint"},
+				{Command: "lint"},
+				{Command: "build"},
+				{Command: "test", Needs: []string{"build"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDependencies(tt.specs)
+
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("ValidateDependencies() unexpected error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("ValidateDependencies() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunSpecs_DependencyOrder(t *testing.T) {
+	marker := t.TempDir() + "/built"
+	specs := []types.CommandSpec{
+		{Name: "check", Command: "ls " + marker, Needs: []string{"build"}},
+		{Name: "build", Command: "sh -c 'sleep 0.1 && touch " + marker + "'"},
+	}
+
+	results := RunSpecs(context.Background(), specs, types.Flags{Threads: 2})
+
+	for _, result := range results {
+		if !result.IsSuccess {
+			t.Errorf("Command %q failed: dependent started before its prerequisite", result.Command)
+		}
+	}
+}
+
+func TestRunSpecs_SkipDependentsOfFailures(t *testing.T) {
+	specs := []types.CommandSpec{
+		{Name: "build", Command: "exit 1"},
+		{Name: "test", Command: "echo test", Needs: []string{"build"}},
+		{Name: "e2e", Command: "echo e2e", Needs: []string{"test"}},
+		{Name: "lint", Command: "echo lint"},
+	}
+
+	start := time.Now()
+	results := RunSpecs(context.Background(), specs, types.Flags{Threads: 1})
+
+	if time.Since(start) > 2*time.Second {
+		t.Fatalf("Dependency scheduling took too long: %v", time.Since(start))
+	}
+
+	if results[1].FailureKind != types.FailureSkipped || results[1].Reason != `dependency "build" failed` {
+		t.Errorf("test: kind=%q reason=%q", results[1].FailureKind, results[1].Reason)
+	}
+
+	if results[2].FailureKind != types.FailureSkipped || results[2].Reason != `dependency "test" was skipped` {
+		t.Errorf("e2e: kind=%q reason=%q", results[2].FailureKind, results[2].Reason)
+	}
+
+	if !results[3].IsSuccess {
+		t.Error("Independent command should still run")
+	}
+}
@@ -0,0 +1,61 @@
+package runner
+
+import (
+	"sort"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/executor"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+// PlanSpecs возвращает план запуска команд в порядке допуска к слотам выполнения, ничего не выполняя:
+// сначала команды без зависимостей в порядке аргументов, затем каждый следующий уровень графа зависимостей
+func PlanSpecs(specs []types.CommandSpec, flags types.Flags) ([]types.CommandPlan, error) {
+	graph, err := resolveDependencies(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	stages := dependencyStages(graph)
+
+	order := make([]int, len(specs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return stages[order[a]] < stages[order[b]]
+	})
+
+	plans := make([]types.CommandPlan, len(specs))
+	for position, index := range order {
+		plan := executor.PlanSpec(specs[index], flags)
+		plan.Order = position + 1
+		plan.Stage = stages[index]
+		plans[position] = plan
+	}
+
+	return plans, nil
+}
+
+// dependencyStages вычисляет для каждой команды длину самой длинной цепочки зависимостей до нее
+func dependencyStages(graph [][]int) []int {
+	stages := make([]int, len(graph))
+	computed := make([]bool, len(graph))
+
+	var stage func(index int) int
+	stage = func(index int) int {
+		if computed[index] {
+			return stages[index]
+		}
+		for _, dep := range graph[index] {
+			stages[index] = max(stages[index], stage(dep)+1)
+		}
+		computed[index] = true
+		return stages[index]
+	}
+
+	for i := range graph {
+		stage(i)
+	}
+
+	return stages
+}
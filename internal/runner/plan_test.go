@@ -0,0 +1,109 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+func TestPlanSpecs(t *testing.T) {
+	specs := []types.CommandSpec{
+		{Name: "e2e", Command: "echo e2e", Needs: []string{"build"}},
+		{Name: "build", Command: "echo build", Needs: []string{"lint"}},
+		{Name: "lint", Command: "echo lint"},
+		{Command: "echo unit"},
+	}
+
+	plans, err := PlanSpecs(specs, types.Flags{Threads: 2})
+	if err != nil {
+		t.Fatalf("PlanSpecs() error = %v", err)
+	}
+
+	wantOrder := []string{"lint", "echo unit", "build", "e2e"}
+	wantStages := []int{0, 0, 1, 2}
+	for i, plan := range plans {
+		ref := plan.Name
+		if ref == "" {
+			ref = plan.Command
+		}
+		if ref != wantOrder[i] || plan.Stage != wantStages[i] || plan.Order != i+1 {
+			t.Errorf("plan[%d] = %q stage %d order %d, want %q stage %d order %d", i, ref, plan.Stage, plan.Order, wantOrder[i], wantStages[i], i+1)
+		}
+	}
+
+	if plans[0].Executable == "" || strings.Join(plans[0].Args, " ") != "echo lint" {
+		t.Errorf("Plan should resolve executable and argv, got %q %q", plans[0].Executable, plans[0].Args)
+	}
+}
+
+func TestPlanSpecs_Cycle(t *testing.T) {
+	specs := []types.CommandSpec{
+		{Name: "a", Command: "echo a", Needs: []string{"b"}},
+		{Name: "b", Command: "echo b", Needs: []string{"a"}},
+	}
+
+	if _, err := PlanSpecs(specs, types.Flags{Threads: 1}); err == nil {
+		t.Error("PlanSpecs() should reject dependency cycles")
+	}
+}
+
+func TestSlots_AdmitInIndexOrder(t *testing.T) {
+	s := newSlots(1, 4)
+	for _, index := range []int{2, 0, 3, 1} {
+		s.enqueue(index)
+	}
+
+	// Первый слот выдан сразу команде 2; дальше очередь обслуживается по возрастанию индекса
+	var (
+		mu    sync.Mutex
+		order []int
+		wg    sync.WaitGroup
+	)
+	for _, index := range []int{2, 0, 3, 1} {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			if !s.wait(context.Background(), index) {
+				t.Errorf("wait(%d) failed", index)
+				return
+			}
+			mu.Lock()
+			order = append(order, index)
+			mu.Unlock()
+			s.release()
+		}(index)
+	}
+	wg.Wait()
+
+	want := []int{2, 0, 1, 3}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("admission order = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestSlots_CancelWhileQueued(t *testing.T) {
+	s := newSlots(1, 2)
+	s.enqueue(0)
+	s.enqueue(1)
+
+	if !s.wait(context.Background(), 0) {
+		t.Fatal("First command should get the free slot")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if s.wait(ctx, 1) {
+		t.Error("Queued command should not get a slot after cancel")
+	}
+
+	s.release()
+	if s.free != 1 || len(s.queue) != 0 {
+		t.Errorf("Slots leaked: free=%d queue=%v", s.free, s.queue)
+	}
+}
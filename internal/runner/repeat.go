@@ -0,0 +1,50 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+// RepeatSpecs запускает набор команд times раз одним прогоном: повторы выполняются параллельно
+// в пределах flags.Threads. Возвращает результаты по повторам: runs[i][j] - команда j в повторе i
+func RepeatSpecs(ctx context.Context, specs []types.CommandSpec, flags types.Flags, times int) [][]types.CommandResult {
+	results := RunSpecs(ctx, repeatSpecs(specs, times), flags)
+
+	runs := make([][]types.CommandResult, times)
+	for i := range runs {
+		runs[i] = results[i*len(specs) : (i+1)*len(specs)]
+	}
+
+	return runs
+}
+
+// repeatSpecs размножает команды по повторам; при наличии зависимостей команды переименовываются
+// в "ref#N", чтобы каждый повтор ждал только свои зависимости
+func repeatSpecs(specs []types.CommandSpec, times int) []types.CommandSpec {
+	hasNeeds := false
+	for _, commandSpec := range specs {
+		if len(commandSpec.Needs) > 0 {
+			hasNeeds = true
+			break
+		}
+	}
+
+	repeated := make([]types.CommandSpec, 0, len(specs)*times)
+	for run := 1; run <= times; run++ {
+		for _, commandSpec := range specs {
+			if hasNeeds {
+				commandSpec.Name = fmt.Sprintf("%s#%d", commandSpec.Ref(), run)
+				needs := make([]string, len(commandSpec.Needs))
+				for i, need := range commandSpec.Needs {
+					needs[i] = fmt.Sprintf("%s#%d", need, run)
+				}
+				commandSpec.Needs = needs
+			}
+			repeated = append(repeated, commandSpec)
+		}
+	}
+
+	return repeated
+}
@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+func TestRepeatSpecs(t *testing.T) {
+	tests := []struct {
+		name      string
+		specs     []types.CommandSpec
+		wantNames []string
+		wantNeeds [][]string
+	}{
+		{
+			name:      "без зависимостей имена не меняются",
+			specs:     []types.CommandSpec{{Command: "lint"}, {Name: "unit", Command: "test"}},
+			wantNames: []string{"", "unit", "", "unit"},
+			wantNeeds: [][]string{nil, nil, nil, nil},
+		},
+		{
+			name:      "зависимости остаются внутри повтора",
+			specs:     []types.CommandSpec{{Command: "build"}, {Command: "test", Needs: []string{"build"}}},
+			wantNames: []string{"build#1", "test#1", "build#2", "test#2"},
+			wantNeeds: [][]string{{}, {"build#1"}, {}, {"build#2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repeated := repeatSpecs(tt.specs, 2)
+
+			if len(repeated) != len(tt.wantNames) {
+				t.Fatalf("Expected %d specs, got %d", len(tt.wantNames), len(repeated))
+			}
+
+			for i, commandSpec := range repeated {
+				if commandSpec.Name != tt.wantNames[i] {
+					t.Errorf("Spec %d name = %q, want %q", i, commandSpec.Name, tt.wantNames[i])
+				}
+				if !reflect.DeepEqual(commandSpec.Needs, tt.wantNeeds[i]) {
+					t.Errorf("Spec %d needs = %v, want %v", i, commandSpec.Needs, tt.wantNeeds[i])
+				}
+				if commandSpec.Command != tt.specs[i%len(tt.specs)].Command {
+					t.Errorf("Spec %d command = %q, want %q", i, commandSpec.Command, tt.specs[i%len(tt.specs)].Command)
+				}
+			}
+		})
+	}
+}
+
+func TestRepeatSpecs_Run(t *testing.T) {
+	specs := []types.CommandSpec{
+		{Command: "echo build"},
+		{Command: "exit 3", Needs: []string{"echo build"}},
+	}
+	flags := types.Flags{Threads: 2}
+
+	runs := RepeatSpecs(context.Background(), specs, flags, 3)
+
+	if len(runs) != 3 {
+		t.Fatalf("Expected 3 runs, got %d", len(runs))
+	}
+
+	for i, results := range runs {
+		if len(results) != len(specs) {
+			t.Fatalf("Run %d: expected %d results, got %d", i, len(specs), len(results))
+		}
+		if !results[0].IsSuccess {
+			t.Errorf("Run %d: build should pass, got %+v", i, results[0])
+		}
+		if results[1].ExitCode != 3 || results[1].FailureKind != types.FailureExit {
+			t.Errorf("Run %d: dependent command should run and exit with 3, got %+v", i, results[1])
+		}
+	}
+}
@@ -2,10 +2,14 @@ package runner
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"runtime"
 	"sync"
 
+	"github.com/CyberWalrus/ai-friendly-runner/internal/events"
 	"github.com/CyberWalrus/ai-friendly-runner/internal/executor"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/spec"
 	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
 )
 
@@ -20,37 +24,166 @@ func GetDefaultThreads() int {
 
 // RunCommands запускает команды параллельно с ограничением потоков
 func RunCommands(ctx context.Context, commands []string, flags types.Flags) []types.CommandResult {
-	results := make([]types.CommandResult, len(commands))
+	return RunSpecs(ctx, spec.FromCommands(commands), flags)
+}
+
+// RunSpecs запускает команды с индивидуальными опциями параллельно с ограничением потоков
+func RunSpecs(ctx context.Context, specs []types.CommandSpec, flags types.Flags) []types.CommandResult {
+	results := make([]types.CommandResult, len(specs))
+
+	if flags.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, flags.Timeout)
+		defer cancel()
+	}
+
+	ctx, abort := context.WithCancelCause(ctx)
+	defer abort(nil)
 
-	semaphore := make(chan struct{}, flags.Threads)
+	threadSlots := newSlots(flags.Threads, len(specs))
+	failures := newFailureCounter(flags.MaxFailures, abort)
+
+	graph, graphErr := resolveDependencies(specs)
+	done := make([]chan struct{}, len(specs))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	events.Publish(ctx, events.Event{
+		Type:     events.RunStarted,
+		Index:    -1,
+		Commands: commandNames(specs),
+		Threads:  flags.Threads,
+	})
 
 	var wg sync.WaitGroup
 
-	for i, command := range commands {
+	for i, commandSpec := range specs {
 		wg.Add(1)
 
-		go func(index int, cmd string) {
-			defer wg.Done()
+		commandCtx := events.WithCommand(ctx, i, commandSpec.Command)
+		events.Publish(commandCtx, events.Event{Type: events.CommandQueued})
 
-			select {
-			case semaphore <- struct{}{}:
-				defer func() { <-semaphore }()
+		// Команды без зависимостей встают в очередь сразу и в порядке аргументов
+		if graphErr == nil && len(graph[i]) == 0 {
+			threadSlots.enqueue(i)
+		}
 
-				result := executor.ExecCommandWithContext(ctx, cmd, flags)
-				results[index] = result
+		go func(index int, cmdSpec types.CommandSpec) {
+			defer wg.Done()
+			defer close(done[index])
+			defer func() {
+				events.Publish(commandCtx, events.Event{Type: events.CommandFinished, Result: &results[index]})
+			}()
 
-			case <-ctx.Done():
+			if graphErr != nil {
 				results[index] = types.CommandResult{
-					Command:   cmd,
-					IsSuccess: false,
-					Stderr:    "Cancelled by user",
-					Duration:  0,
+					Command:     cmdSpec.Command,
+					IsSuccess:   false,
+					Stderr:      graphErr.Error() + "\n",
+					ExitCode:    -1,
+					FailureKind: types.FailureStart,
 				}
+				return
+			}
+
+			// Зависимости ожидаются до захвата слота, чтобы ожидающие команды не занимали потоки
+			if reason, ok := waitForDependencies(ctx, graph[index], done, results, specs); !ok {
+				if reason == "" {
+					results[index] = notStartedResult(ctx, cmdSpec)
+				} else {
+					results[index] = skippedResult(cmdSpec, reason)
+				}
+				return
+			}
+
+			if len(graph[index]) > 0 {
+				threadSlots.enqueue(index)
+			}
+
+			if !threadSlots.wait(ctx, index) {
+				results[index] = notStartedResult(ctx, cmdSpec)
+				return
+			}
+			defer threadSlots.release()
+
+			if ctx.Err() != nil {
+				results[index] = notStartedResult(ctx, cmdSpec)
+				return
+			}
+
+			result := executor.ExecSpecWithContext(commandCtx, cmdSpec, flags)
+			if result.FailureKind == types.FailureCancelled {
+				result.Reason = abortReason(ctx)
 			}
-		}(i, command)
+			results[index] = result
+			failures.record(cmdSpec, result)
+		}(i, commandSpec)
 	}
 
 	wg.Wait()
 
+	events.Publish(ctx, events.Event{Type: events.RunFinished, Index: -1, Results: results})
+
 	return results
 }
+
+func commandNames(specs []types.CommandSpec) []string {
+	names := make([]string, len(specs))
+	for i, commandSpec := range specs {
+		names[i] = commandSpec.Command
+	}
+	return names
+}
+
+// waitForDependencies ждет завершения зависимостей; возвращает false и причину, если команду нельзя запускать
+func waitForDependencies(ctx context.Context, deps []int, done []chan struct{}, results []types.CommandResult, specs []types.CommandSpec) (string, bool) {
+	for _, dep := range deps {
+		select {
+		case <-done[dep]:
+		case <-ctx.Done():
+			return "", false
+		}
+
+		if !results[dep].IsSuccess {
+			verb := "failed"
+			if results[dep].FailureKind == types.FailureSkipped {
+				verb = "was skipped"
+			}
+			return fmt.Sprintf("dependency %q %s", specs[dep].Ref(), verb), false
+		}
+	}
+
+	return "", true
+}
+
+// skippedResult формирует результат для команды, которая не запускалась по указанной причине
+func skippedResult(cmdSpec types.CommandSpec, reason string) types.CommandResult {
+	return types.CommandResult{
+		Command:     cmdSpec.Command,
+		IsSuccess:   false,
+		ExitCode:    -1,
+		FailureKind: types.FailureSkipped,
+		Reason:      reason,
+	}
+}
+
+// notStartedResult формирует результат для команды, которая так и не получила слот выполнения
+func notStartedResult(ctx context.Context, cmdSpec types.CommandSpec) types.CommandResult {
+	if reason := abortReason(ctx); reason != "" {
+		return skippedResult(cmdSpec, reason)
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return skippedResult(cmdSpec, "global timeout expired while queued")
+	}
+
+	return types.CommandResult{
+		Command:     cmdSpec.Command,
+		IsSuccess:   false,
+		Stderr:      "Cancelled by user",
+		Duration:    0,
+		ExitCode:    -1,
+		FailureKind: types.FailureCancelled,
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/CyberWalrus/ai-friendly-runner/internal/events"
 	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
 )
 
@@ -136,3 +137,131 @@ func TestRunCommands_EmptyList(t *testing.T) {
 		t.Errorf("Expected 0 results for empty commands, got %d", len(results))
 	}
 }
+
+func TestRunCommands_GlobalTimeout(t *testing.T) {
+	commands := []string{"sleep 5", "sleep 5"}
+	flags := types.Flags{
+		Threads:     1,
+		Timeout:     100 * time.Millisecond,
+		GracePeriod: 50 * time.Millisecond,
+	}
+
+	start := time.Now()
+	results := RunCommands(context.Background(), commands, flags)
+
+	if time.Since(start) > 2*time.Second {
+		t.Fatalf("Global timeout was not enforced: %v", time.Since(start))
+	}
+
+	kinds := map[types.FailureKind]int{}
+	for _, result := range results {
+		kinds[result.FailureKind]++
+	}
+
+	if kinds[types.FailureTimeout] != 1 || kinds[types.FailureSkipped] != 1 {
+		t.Errorf("Expected one running command timed out and one queued skipped, got %v", kinds)
+	}
+}
+
+func TestRunSpecs_Events(t *testing.T) {
+	bus := events.NewBus()
+
+	var received []events.Event
+	bus.Subscribe(func(event events.Event) {
+		received = append(received, event)
+	})
+
+	ctx := events.WithBus(context.Background(), bus)
+	RunCommands(ctx, []string{"echo hello"}, types.Flags{Threads: 1})
+
+	want := []events.Type{
+		events.RunStarted,
+		events.CommandQueued,
+		events.CommandStarted,
+		events.OutputLine,
+		events.CommandFinished,
+		events.RunFinished,
+	}
+
+	if len(received) != len(want) {
+		t.Fatalf("Expected %d events, got %d: %+v", len(want), len(received), received)
+	}
+
+	for i, event := range received {
+		if event.Type != want[i] {
+			t.Errorf("Event %d type = %q, want %q", i, event.Type, want[i])
+		}
+	}
+
+	if received[3].Line != "hello" || received[3].Command != "echo hello" {
+		t.Errorf("Unexpected output line event: %+v", received[3])
+	}
+}
+
+func TestRunCommands_FailFast(t *testing.T) {
+	commands := []string{"exit 2", "sleep 5", "sleep 4"}
+	flags := types.Flags{
+		Output:      "errors",
+		Threads:     3,
+		GracePeriod: 100 * time.Millisecond,
+		MaxFailures: 1,
+	}
+
+	start := time.Now()
+	results := RunCommands(context.Background(), commands, flags)
+	elapsed := time.Since(start)
+
+	if elapsed > 3*time.Second {
+		t.Errorf("Fail-fast run took %v, running command was not stopped", elapsed)
+	}
+
+	if results[0].FailureKind != types.FailureExit {
+		t.Errorf("Trigger FailureKind = %q, want %q", results[0].FailureKind, types.FailureExit)
+	}
+
+	wantReason := `run aborted after "exit 2" failed`
+
+	for _, result := range results[1:] {
+		if result.FailureKind != types.FailureCancelled {
+			t.Errorf("%q: FailureKind = %q, want cancelled", result.Command, result.FailureKind)
+		}
+		if result.Reason != wantReason {
+			t.Errorf("%q: Reason = %q, want %q", result.Command, result.Reason, wantReason)
+		}
+	}
+}
+
+func TestRunCommands_MaxFailures(t *testing.T) {
+	commands := []string{"exit 1", "sleep 0.2 && exit 2", "sleep 5"}
+	flags := types.Flags{
+		Output:      "errors",
+		Threads:     3,
+		GracePeriod: 100 * time.Millisecond,
+		MaxFailures: 2,
+	}
+
+	results := RunCommands(context.Background(), commands, flags)
+
+	if results[1].FailureKind != types.FailureExit {
+		t.Errorf("Second failure should still run, got kind %q", results[1].FailureKind)
+	}
+
+	wantReason := `run aborted after 2 failures, last "sleep 0.2 && exit 2"`
+	if results[2].FailureKind != types.FailureCancelled || results[2].Reason != wantReason {
+		t.Errorf("Running command: kind %q reason %q, want cancelled with %q", results[2].FailureKind, results[2].Reason, wantReason)
+	}
+}
+
+func TestNotStartedResult_Aborted(t *testing.T) {
+	ctx, abort := context.WithCancelCause(context.Background())
+	abort(&abortError{reason: `run aborted after "lint" failed`})
+
+	result := notStartedResult(ctx, types.CommandSpec{Command: "echo queued"})
+
+	if result.FailureKind != types.FailureSkipped {
+		t.Errorf("FailureKind = %q, want %q", result.FailureKind, types.FailureSkipped)
+	}
+	if result.Reason != `run aborted after "lint" failed` {
+		t.Errorf("Reason = %q", result.Reason)
+	}
+}
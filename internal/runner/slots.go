@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// slots ограничивает число одновременно выполняемых команд; свободный слот всегда получает
+// готовая к запуску команда с наименьшим индексом, поэтому порядок допуска предсказуем
+type slots struct {
+	mu      sync.Mutex
+	free    int
+	queue   []int // индексы готовых к запуску команд по возрастанию
+	granted []chan struct{}
+}
+
+func newSlots(threads, count int) *slots {
+	granted := make([]chan struct{}, count)
+	for i := range granted {
+		granted[i] = make(chan struct{})
+	}
+	return &slots{free: threads, granted: granted}
+}
+
+// enqueue ставит готовую к запуску команду в очередь на слот
+func (s *slots) enqueue(index int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	position := sort.SearchInts(s.queue, index)
+	s.queue = append(s.queue, 0)
+	copy(s.queue[position+1:], s.queue[position:])
+	s.queue[position] = index
+
+	s.dispatch()
+}
+
+// wait ждет слот для команды из очереди; возвращает false, если контекст отменен раньше
+func (s *slots) wait(ctx context.Context, index int) bool {
+	select {
+	case <-s.granted[index]:
+		return true
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	for i, queued := range s.queue {
+		if queued == index {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			s.mu.Unlock()
+			return false
+		}
+	}
+	s.mu.Unlock()
+
+	// Слот уже был выдан одновременно с отменой - возвращаем его
+	s.release()
+	return false
+}
+
+// release освобождает слот и передает его следующей команде в очереди
+func (s *slots) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.free++
+	s.dispatch()
+}
+
+func (s *slots) dispatch() {
+	for s.free > 0 && len(s.queue) > 0 {
+		index := s.queue[0]
+		s.queue = s.queue[1:]
+		s.free--
+		close(s.granted[index])
+	}
+}
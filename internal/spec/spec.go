@@ -0,0 +1,261 @@
+package spec
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+// labelPattern совпадает с подписью "name: command". Подпись отделяется двоеточием с пробелом, а не "=",
+// поэтому присваивания "http_proxy=x env" и "NODE_ENV=test node app.js" остаются командами
+var labelPattern = regexp.MustCompile(`^([a-z][a-z0-9_:.-]*): +(\S.*)$`)
+
+// Parse разбирает аргумент CLI вида "[opt,key=value]name: command" в CommandSpec; опции и подпись необязательны
+func Parse(arg string) (types.CommandSpec, error) {
+	options, command, ok := splitOptions(arg)
+	if !ok {
+		if name, labeled, ok := splitLabel(arg); ok {
+			return types.CommandSpec{Name: name, Command: labeled}, nil
+		}
+		return types.CommandSpec{Command: arg}, nil
+	}
+
+	spec := types.CommandSpec{Command: strings.TrimSpace(command)}
+	if spec.Command == "" {
+		return spec, fmt.Errorf("empty command after options in %q", arg)
+	}
+
+	for _, option := range strings.Split(options, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(option), "=")
+		if err := applyOption(&spec, key, value); err != nil {
+			return spec, fmt.Errorf("%w in %q", err, arg)
+		}
+	}
+
+	// Явная опция name отключает разбор подписи: команда остается как есть
+	if spec.Name == "" {
+		if name, labeled, ok := splitLabel(spec.Command); ok {
+			spec.Name, spec.Command = name, labeled
+		}
+	}
+
+	return spec, nil
+}
+
+// splitLabel отделяет подпись "name: " от команды
+func splitLabel(arg string) (string, string, bool) {
+	match := labelPattern.FindStringSubmatch(arg)
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], strings.TrimSpace(match[2]), true
+}
+
+// ParseAll разбирает список аргументов CLI; аргументы вида "build -> test,e2e" задают зависимости,
+// а упомянутые в них, но не перечисленные отдельно команды добавляются в конец списка
+func ParseAll(args []string) ([]types.CommandSpec, error) {
+	specs := make([]types.CommandSpec, 0, len(args))
+	dependencies := [][][]string{}
+
+	for _, arg := range args {
+		stages, ok, err := parseDependencies(arg)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			dependencies = append(dependencies, stages)
+			continue
+		}
+
+		spec, err := Parse(arg)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+
+	for _, stages := range dependencies {
+		specs = applyDependencies(specs, stages)
+	}
+
+	return specs, nil
+}
+
+// parseDependencies разбирает объявление "a -> b,c -> d" в список этапов. Аргумент с " -> ", который не
+// разбирается как объявление, - ошибка, а не команда: в shell "go build -> go test" перезаписал бы файл "go"
+func parseDependencies(arg string) ([][]string, bool, error) {
+	if !strings.Contains(arg, "->") {
+		return nil, false, nil
+	}
+
+	stages := [][]string{}
+	for _, segment := range strings.Split(arg, "->") {
+		names := []string{}
+		for _, name := range strings.Split(segment, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" || strings.ContainsAny(name, " \t") {
+				if strings.Contains(arg, " -> ") {
+					return nil, false, fmt.Errorf("invalid dependency reference %q in %q: give multi-word commands a name with \"name: command\" or [name=...] and reference the name", name, arg)
+				}
+				return nil, false, nil
+			}
+			names = append(names, name)
+		}
+		stages = append(stages, names)
+	}
+
+	return stages, len(stages) > 1, nil
+}
+
+// applyDependencies добавляет каждой команде этапа зависимости от всех команд предыдущего этапа
+func applyDependencies(specs []types.CommandSpec, stages [][]string) []types.CommandSpec {
+	indexOf := func(name string) int {
+		for i, spec := range specs {
+			if spec.Ref() == name {
+				return i
+			}
+		}
+		specs = append(specs, types.CommandSpec{Command: name})
+		return len(specs) - 1
+	}
+
+	for i, stage := range stages {
+		for _, name := range stage {
+			index := indexOf(name)
+			if i == 0 {
+				continue
+			}
+			for _, need := range stages[i-1] {
+				if !contains(specs[index].Needs, need) {
+					specs[index].Needs = append(specs[index].Needs, need)
+				}
+			}
+		}
+	}
+
+	return specs
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// FromCommands превращает строки команд в CommandSpec без разбора опций
+func FromCommands(commands []string) []types.CommandSpec {
+	specs := make([]types.CommandSpec, len(commands))
+	for i, command := range commands {
+		specs[i] = types.CommandSpec{Command: command}
+	}
+	return specs
+}
+
+// splitOptions отделяет блок опций "[...]" от команды; "[ -f x ]" и подобные команды не считаются опциями
+func splitOptions(arg string) (string, string, bool) {
+	if len(arg) < 3 || arg[0] != '[' {
+		return "", "", false
+	}
+
+	first := arg[1]
+	if !(first >= 'a' && first <= 'z') {
+		return "", "", false
+	}
+
+	end := strings.IndexByte(arg, ']')
+	if end < 0 {
+		return "", "", false
+	}
+
+	return arg[1:end], arg[end+1:], true
+}
+
+func applyOption(spec *types.CommandSpec, key, value string) error {
+	switch key {
+	case "shell":
+		enabled, err := parseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid shell option: %w", err)
+		}
+		spec.Shell = types.ShellNever
+		if enabled {
+			spec.Shell = types.ShellAlways
+		}
+	case "name":
+		if value == "" || strings.ContainsAny(value, " \t,") {
+			return fmt.Errorf("invalid name %q", value)
+		}
+		spec.Name = value
+	case "needs":
+		if value == "" {
+			return fmt.Errorf("needs option requires a command name")
+		}
+		spec.Needs = append(spec.Needs, value)
+	case "timeout":
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid timeout %q", value)
+		}
+		spec.Timeout = timeout
+	case "retries":
+		retries, err := strconv.Atoi(value)
+		if err != nil || retries < 0 {
+			return fmt.Errorf("invalid retries %q", value)
+		}
+		spec.Retries = &retries
+	case "cwd":
+		if value == "" {
+			return fmt.Errorf("cwd option requires a directory")
+		}
+		spec.Dir = value
+	case "env":
+		if name, _, ok := strings.Cut(value, "="); !ok || name == "" {
+			return fmt.Errorf("invalid env %q, expected KEY=VALUE", value)
+		}
+		spec.Env = append(spec.Env, value)
+	case "env-file":
+		if value == "" {
+			return fmt.Errorf("env-file option requires a path")
+		}
+		spec.EnvFiles = append(spec.EnvFiles, value)
+	case "env-allow":
+		if value == "" {
+			return fmt.Errorf("env-allow option requires a variable name")
+		}
+		spec.EnvAllow = append(spec.EnvAllow, value)
+	case "clean-env":
+		clean, err := parseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid clean-env option: %w", err)
+		}
+		spec.CleanEnv = clean
+	case "max-lines", "max-tokens":
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			return fmt.Errorf("invalid %s %q", key, value)
+		}
+		if key == "max-lines" {
+			spec.MaxOutputLines = &limit
+		} else {
+			spec.MaxOutputTokens = &limit
+		}
+	default:
+		return fmt.Errorf("unknown command option %q", key)
+	}
+
+	return nil
+}
+
+func parseBool(value string) (bool, error) {
+	if value == "" {
+		return true, nil
+	}
+	return strconv.ParseBool(value)
+}
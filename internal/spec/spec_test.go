@@ -0,0 +1,209 @@
+package spec
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		arg  string
+		want types.CommandSpec
+	}{
+		{
+			name: "команда без опций",
+			arg:  "yarn lint",
+			want: types.CommandSpec{Command: "yarn lint"},
+		},
+		{
+			name: "shell опция",
+			arg:  "[shell]make check",
+			want: types.CommandSpec{Command: "make check", Shell: types.ShellAlways},
+		},
+		{
+			name: "отключение shell",
+			arg:  "[shell=false]echo a|b",
+			want: types.CommandSpec{Command: "echo a|b", Shell: types.ShellNever},
+		},
+		{
+			name: "таймаут и shell",
+			arg:  "[shell,timeout=30s]yarn e2e",
+			want: types.CommandSpec{Command: "yarn e2e", Shell: types.ShellAlways, Timeout: 30 * time.Second},
+		},
+		{
+			name: "имя и зависимости",
+			arg:  "[name=e2e,needs=build,needs=lint]yarn test:e2e",
+			want: types.CommandSpec{Name: "e2e", Command: "yarn test:e2e", Needs: []string{"build", "lint"}},
+		},
+		{
+			name: "повторы",
+			arg:  "[retries=2]yarn e2e",
+			want: types.CommandSpec{Command: "yarn e2e", Retries: intPtr(2)},
+		},
+		{
+			name: "отключение повторов",
+			arg:  "[retries=0]yarn lint",
+			want: types.CommandSpec{Command: "yarn lint", Retries: intPtr(0)},
+		},
+		{
+			name: "лимиты вывода",
+			arg:  "[max-lines=200,max-tokens=0]yarn test",
+			want: types.CommandSpec{Command: "yarn test", MaxOutputLines: intPtr(200), MaxOutputTokens: intPtr(0)},
+		},
+		{
+			name: "рабочая директория и окружение",
+			arg:  "[cwd=packages/api,env=NODE_ENV=test,env=DEBUG=,env-file=.env.test]yarn test",
+			want: types.CommandSpec{Command: "yarn test", Dir: "packages/api", Env: []string{"NODE_ENV=test", "DEBUG="}, EnvFiles: []string{".env.test"}},
+		},
+		{
+			name: "очищенное окружение",
+			arg:  "[clean-env,env-allow=HOME,env-allow=NPM_*]npm test",
+			want: types.CommandSpec{Command: "npm test", EnvAllow: []string{"HOME", "NPM_*"}, CleanEnv: true},
+		},
+		{
+			name: "подпись команды",
+			arg:  "api: yarn dev --port 3000",
+			want: types.CommandSpec{Name: "api", Command: "yarn dev --port 3000"},
+		},
+		{
+			name: "подпись с двоеточием после опций",
+			arg:  "[timeout=30s]test:e2e: yarn playwright test",
+			want: types.CommandSpec{Name: "test:e2e", Command: "yarn playwright test", Timeout: 30 * time.Second},
+		},
+		{
+			name: "опция name отключает подпись",
+			arg:  "[name=flaky]api: yarn dev",
+			want: types.CommandSpec{Name: "flaky", Command: "api: yarn dev"},
+		},
+		{
+			name: "npm скрипт с двоеточием не подпись",
+			arg:  "lint:eslint",
+			want: types.CommandSpec{Command: "lint:eslint"},
+		},
+		{
+			name: "url в аргументе не подпись",
+			arg:  "curl http://localhost:3000",
+			want: types.CommandSpec{Command: "curl http://localhost:3000"},
+		},
+		{
+			name: "префикс с переменной окружения в нижнем регистре",
+			arg:  "foo=bar cmd",
+			want: types.CommandSpec{Command: "foo=bar cmd"},
+		},
+		{
+			name: "http_proxy перед командой",
+			arg:  "http_proxy=x env",
+			want: types.CommandSpec{Command: "http_proxy=x env"},
+		},
+		{
+			name: "присваивание после опций",
+			arg:  "[timeout=30s]greeting=hello sh -c 'echo $greeting'",
+			want: types.CommandSpec{Command: "greeting=hello sh -c 'echo $greeting'", Timeout: 30 * time.Second},
+		},
+		{
+			name: "переменная окружения перед командой",
+			arg:  "NODE_ENV=test node app.js",
+			want: types.CommandSpec{Command: "NODE_ENV=test node app.js"},
+		},
+		{
+			name: "присваивание shell переменной",
+			arg:  "n=$(cat count); echo $n",
+			want: types.CommandSpec{Command: "n=$(cat count); echo $n"},
+		},
+		{
+			name: "присваивание перед командой shell",
+			arg:  "count=0; make",
+			want: types.CommandSpec{Command: "count=0; make"},
+		},
+		{
+			name: "опция name с присваиванием в команде",
+			arg:  "[name=flaky]n=1 sh run.sh",
+			want: types.CommandSpec{Name: "flaky", Command: "n=1 sh run.sh"},
+		},
+		{
+			name: "знак равенства в аргументе",
+			arg:  "echo a=b",
+			want: types.CommandSpec{Command: "echo a=b"},
+		},
+		{
+			name: "команда test в квадратных скобках",
+			arg:  "[ -f go.mod ]",
+			want: types.CommandSpec{Command: "[ -f go.mod ]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.arg)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.arg, err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.arg, got, tt.want)
+			}
+		})
+	}
+}
+
+func intPtr(value int) *int {
+	return &value
+}
+
+func TestParse_Errors(t *testing.T) {
+	args := []string{"[unknown]lint", "[shell]", "[shell=maybe]lint", "[timeout=abc]lint", "[timeout=-1s]lint", "[retries=-1]lint", "[retries=x]lint", "[max-lines=-5]lint", "[max-tokens=many]lint", "[cwd=]lint", "[env=DEBUG]lint", "[env==1]lint", "[env-file=]lint", "[env-allow=]lint", "[clean-env=maybe]lint"}
+
+	for _, arg := range args {
+		if _, err := Parse(arg); err == nil {
+			t.Errorf("Parse(%q) expected error", arg)
+		}
+	}
+}
+
+func TestParseAll_Dependencies(t *testing.T) {
+	specs, err := ParseAll([]string{"[name=build]yarn build", "test", "build -> test,e2e", "echo a->b"})
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+
+	want := []types.CommandSpec{
+		{Name: "build", Command: "yarn build"},
+		{Command: "test", Needs: []string{"build"}},
+		{Command: "echo a->b"},
+		{Command: "e2e", Needs: []string{"build"}},
+	}
+
+	if !reflect.DeepEqual(specs, want) {
+		t.Errorf("ParseAll() = %+v, want %+v", specs, want)
+	}
+}
+
+func TestParseAll_DependencyChain(t *testing.T) {
+	specs, err := ParseAll([]string{"lint,typecheck -> build -> e2e"})
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+
+	want := []types.CommandSpec{
+		{Command: "lint"},
+		{Command: "typecheck"},
+		{Command: "build", Needs: []string{"lint", "typecheck"}},
+		{Command: "e2e", Needs: []string{"build"}},
+	}
+
+	if !reflect.DeepEqual(specs, want) {
+		t.Errorf("ParseAll() = %+v, want %+v", specs, want)
+	}
+}
+
+func TestParseAll_InvalidDependencyReference(t *testing.T) {
+	for _, arg := range []string{"false -> echo t,echo e", "go build ./... -> go test ./...", "build -> "} {
+		if _, err := ParseAll([]string{arg}); err == nil {
+			t.Errorf("ParseAll(%q) expected error instead of running it as a command", arg)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+// ErrNoLastRun возвращается, если в директории еще не было запусков aifr
+var ErrNoLastRun = errors.New("no previous run found (run aifr first)")
+
+// Dir - директория состояния aifr относительно рабочей директории запуска
+const Dir = ".aifr"
+
+// FileName - имя файла с последним запуском
+const FileName = "last-run.json"
+
+// LastRun содержит все, что нужно, чтобы повторить или заново вывести последний запуск. У Specs
+// сохраняются только имена переменных Env: rerun восстанавливает значения из конфигурации
+type LastRun struct {
+	Info    types.RunInfo         `json:"info"`
+	Flags   types.Flags           `json:"flags"`
+	Specs   []types.CommandSpec   `json:"specs"`
+	Results []types.CommandResult `json:"results"`
+}
+
+// Path возвращает путь к файлу последнего запуска для dir
+func Path(dir string) string {
+	return filepath.Join(dir, Dir, FileName)
+}
+
+// Save атомарно записывает последний запуск в dir/.aifr/last-run.json; значения переменных Env
+// могут быть секретами, поэтому в файл попадают только их имена
+func Save(dir string, run LastRun) error {
+	run.Specs = withEnvNames(run.Specs)
+
+	path := Path(dir)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), FileName+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// withEnvNames возвращает копию команд, в Env которых вместо KEY=VALUE остались только имена
+func withEnvNames(specs []types.CommandSpec) []types.CommandSpec {
+	redacted := make([]types.CommandSpec, len(specs))
+	for i, spec := range specs {
+		if len(spec.Env) > 0 {
+			names := make([]string, len(spec.Env))
+			for j, entry := range spec.Env {
+				names[j], _, _ = strings.Cut(entry, "=")
+			}
+			spec.Env = names
+		}
+		redacted[i] = spec
+	}
+	return redacted
+}
+
+// Load читает последний запуск из dir/.aifr/last-run.json
+func Load(dir string) (*LastRun, error) {
+	data, err := os.ReadFile(Path(dir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoLastRun
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	run := &LastRun{}
+	if err := json.Unmarshal(data, run); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", Path(dir), err)
+	}
+
+	if len(run.Specs) != len(run.Results) {
+		return nil, fmt.Errorf("invalid state file %s: %d commands but %d results", Path(dir), len(run.Specs), len(run.Results))
+	}
+
+	return run, nil
+}
+
+// FailedSpecs возвращает команды, которые не прошли в последнем запуске, включая пропущенные и отмененные.
+// Зависимости от успешно выполненных команд убираются: они уже удовлетворены
+func (r *LastRun) FailedSpecs() []types.CommandSpec {
+	failed := map[string]bool{}
+	for i, result := range r.Results {
+		if !result.IsSuccess {
+			failed[r.Specs[i].Ref()] = true
+		}
+	}
+
+	specs := []types.CommandSpec{}
+	for i, result := range r.Results {
+		if result.IsSuccess {
+			continue
+		}
+
+		spec := r.Specs[i]
+		needs := []string{}
+		for _, need := range spec.Needs {
+			if failed[need] {
+				needs = append(needs, need)
+			}
+		}
+		spec.Needs = nil
+		if len(needs) > 0 {
+			spec.Needs = needs
+		}
+
+		specs = append(specs, spec)
+	}
+
+	return specs
+}
@@ -0,0 +1,86 @@
+package state
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	run := LastRun{
+		Info:  types.RunInfo{Version: "1.2.3", Cwd: dir, Threads: 2, StartedAt: time.Unix(100, 0).UTC(), FinishedAt: time.Unix(101, 0).UTC()},
+		Flags: types.Flags{Output: "full", Format: "text", Threads: 2, CommandTimeout: time.Minute, MaxFailures: 1},
+		Specs: []types.CommandSpec{
+			{Name: "build", Command: "go build ./...", Timeout: time.Minute},
+			{Command: "echo ok", Needs: []string{"build"}, Env: []string{"TOKEN=secret"}},
+		},
+		Results: []types.CommandResult{
+			{Command: "go build ./...", IsSuccess: false, ExitCode: 1, FailureKind: types.FailureExit, Stderr: "boom\n"},
+			{Command: "echo ok", ExitCode: -1, FailureKind: types.FailureSkipped, Reason: `dependency "build" failed`},
+		},
+	}
+
+	if err := Save(dir, run); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	data, err := os.ReadFile(Path(dir))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("Env values should not be saved, got:\n%s", data)
+	}
+	if run.Specs[1].Env[0] != "TOKEN=secret" {
+		t.Errorf("Save() should not modify the run, got Env %v", run.Specs[1].Env)
+	}
+
+	want := run
+	want.Specs = []types.CommandSpec{run.Specs[0], run.Specs[1]}
+	want.Specs[1].Env = []string{"TOKEN"}
+	if !reflect.DeepEqual(*loaded, want) {
+		t.Errorf("Load() = %+v, want %+v", *loaded, want)
+	}
+}
+
+func TestLoad_NoLastRun(t *testing.T) {
+	if _, err := Load(t.TempDir()); !errors.Is(err, ErrNoLastRun) {
+		t.Errorf("Load() error = %v, want ErrNoLastRun", err)
+	}
+}
+
+func TestFailedSpecs(t *testing.T) {
+	run := LastRun{
+		Specs: []types.CommandSpec{
+			{Name: "lint", Command: "yarn lint"},
+			{Name: "build", Command: "yarn build"},
+			{Name: "e2e", Command: "yarn e2e", Needs: []string{"lint", "build"}},
+		},
+		Results: []types.CommandResult{
+			{IsSuccess: true},
+			{FailureKind: types.FailureExit},
+			{FailureKind: types.FailureSkipped},
+		},
+	}
+
+	want := []types.CommandSpec{
+		{Name: "build", Command: "yarn build"},
+		// lint уже прошел, поэтому остается только зависимость от build
+		{Name: "e2e", Command: "yarn e2e", Needs: []string{"build"}},
+	}
+
+	if got := run.FailedSpecs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("FailedSpecs() = %+v, want %+v", got, want)
+	}
+}
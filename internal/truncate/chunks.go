@@ -0,0 +1,52 @@
+package truncate
+
+import (
+	"strings"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+// Chunks ограничивает вывод команды в порядке чтения так же, как Text. Строка остается в потоке куска,
+// в котором началась, и получает его время; маркер пропуска относится к потоку первой скрытой строки
+func Chunks(chunks []types.OutputChunk, limits Limits, logPath string) []types.OutputChunk {
+	var b strings.Builder
+	sources := []types.OutputChunk{} // кусок, в котором начинается каждая строка
+	lineStart := true
+	for _, chunk := range chunks {
+		for _, line := range strings.SplitAfter(chunk.Text, "\n") {
+			if line == "" {
+				continue
+			}
+			if lineStart {
+				sources = append(sources, chunk)
+			}
+			lineStart = strings.HasSuffix(line, "\n")
+		}
+		b.WriteString(chunk.Text)
+	}
+
+	text := b.String()
+	if fits(text, limits) {
+		return chunks
+	}
+
+	entries, _ := limitEntries(text, limits, logPath)
+	trailingNewline := strings.HasSuffix(text, "\n")
+
+	limited := []types.OutputChunk{}
+	for i, item := range entries {
+		line := item.text
+		if i < len(entries)-1 || trailingNewline {
+			line += "\n"
+		}
+
+		source := sources[item.first]
+		if n := len(limited); n > 0 && limited[n-1].Stream == source.Stream {
+			limited[n-1].Text += line
+			continue
+		}
+		limited = append(limited, types.OutputChunk{Stream: source.Stream, Time: source.Time, Text: line})
+	}
+
+	return limited
+}
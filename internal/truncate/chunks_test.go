@@ -0,0 +1,54 @@
+package truncate
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+func TestChunks(t *testing.T) {
+	started := time.Date(2025, 11, 4, 10, 0, 0, 0, time.UTC)
+	chunks := []types.OutputChunk{
+		{Stream: "stdout", Time: started, Text: strings.Join(numberedLines(1, 10), "\n") + "\n"},
+		{Stream: "stderr", Time: started.Add(time.Second), Text: "FAIL: expected 1\n"},
+		{Stream: "stdout", Time: started.Add(2 * time.Second), Text: strings.Join(numberedLines(11, 20), "\n") + "\n"},
+	}
+
+	got := Chunks(chunks, Limits{Lines: 6}, "run.log")
+
+	var streams []string
+	var text strings.Builder
+	for _, chunk := range got {
+		streams = append(streams, chunk.Stream)
+		text.WriteString(chunk.Text)
+	}
+
+	if !strings.Contains(text.String(), "line 1\n") || !strings.Contains(text.String(), "line 20\n") {
+		t.Errorf("Expected head and tail to be kept, got:\n%s", text.String())
+	}
+	if strings.Contains(text.String(), "line 5\n") || !strings.Contains(text.String(), "lines omitted (full log at run.log)") {
+		t.Errorf("Expected the middle to be omitted, got:\n%s", text.String())
+	}
+
+	errorChunk := -1
+	for i, chunk := range got {
+		if strings.Contains(chunk.Text, "FAIL: expected 1") {
+			errorChunk = i
+		}
+	}
+	if errorChunk < 0 || got[errorChunk].Stream != "stderr" || !got[errorChunk].Time.Equal(started.Add(time.Second)) {
+		t.Errorf("Error line should stay in stderr with its time, got streams %v", streams)
+	}
+}
+
+func TestChunks_WithinLimits(t *testing.T) {
+	chunks := []types.OutputChunk{{Stream: "stdout", Text: "ok\n"}, {Stream: "stderr", Text: "warn"}}
+
+	got := Chunks(chunks, Limits{Lines: 10}, "")
+
+	if len(got) != 2 || got[1].Text != "warn" {
+		t.Errorf("Output within limits should not change, got %+v", got)
+	}
+}
@@ -0,0 +1,55 @@
+package truncate
+
+import (
+	"errors"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+// LimitsFor возвращает ограничения вывода команды: опции команды имеют приоритет над флагами
+func LimitsFor(spec types.CommandSpec, flags types.Flags) Limits {
+	limits := Limits{Lines: flags.MaxOutputLines, Tokens: flags.MaxOutputTokens}
+	if spec.MaxOutputLines != nil {
+		limits.Lines = *spec.MaxOutputLines
+	}
+	if spec.MaxOutputTokens != nil {
+		limits.Tokens = *spec.MaxOutputTokens
+	}
+	return limits
+}
+
+// Saver сохраняет полный вывод команды с индексом index и возвращает путь к логу
+type Saver func(index int, output string) (string, error)
+
+// Results возвращает копию результатов с обрезанным выводом команд, превышающим лимиты; полный вывод
+// таких команд сохраняется через save. Исходные результаты не меняются: JUnit, JSON файл отчета
+// и последний запуск строятся по полному выводу. Если лог записать не удалось, вывод все равно
+// обрезается, а ошибка возвращается для предупреждения
+func Results(results []types.CommandResult, specs []types.CommandSpec, flags types.Flags, save Saver) ([]types.CommandResult, error) {
+	var errs []error
+
+	truncated := make([]types.CommandResult, len(results))
+	copy(truncated, results)
+
+	for i := range truncated {
+		result := &truncated[i]
+		limits := LimitsFor(specs[i], flags)
+		if !limits.Enabled() || fits(result.Stdout+result.Stderr, limits) {
+			continue
+		}
+
+		logPath, err := save(i, result.CombinedOutput())
+		if err != nil {
+			errs = append(errs, err)
+			logPath = ""
+		}
+
+		result.Stdout, result.Stderr, result.OmittedLines = Output(result.Stdout, result.Stderr, limits, logPath)
+		if len(result.Output) > 0 {
+			result.Output = Chunks(result.Output, limits, logPath)
+		}
+		result.FullLog = logPath
+	}
+
+	return truncated, errors.Join(errs...)
+}
@@ -0,0 +1,80 @@
+package truncate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/CyberWalrus/ai-friendly-runner/internal/logs"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
+)
+
+func TestLimitsFor(t *testing.T) {
+	flags := types.Flags{MaxOutputLines: 100, MaxOutputTokens: 2000}
+	zero := 0
+	lines := 10
+
+	tests := []struct {
+		name string
+		spec types.CommandSpec
+		want Limits
+	}{
+		{"флаги по умолчанию", types.CommandSpec{}, Limits{Lines: 100, Tokens: 2000}},
+		{"лимит команды", types.CommandSpec{MaxOutputLines: &lines}, Limits{Lines: 10, Tokens: 2000}},
+		{"отключение лимита", types.CommandSpec{MaxOutputTokens: &zero}, Limits{Lines: 100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LimitsFor(tt.spec, flags); got != tt.want {
+				t.Errorf("LimitsFor() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResults(t *testing.T) {
+	logDir := filepath.Join(t.TempDir(), "logs")
+	long := strings.Join(numberedLines(1, 10), "\n") + "\nFAIL: expected 1\n" + strings.Join(numberedLines(11, 20), "\n") + "\n"
+
+	specs := []types.CommandSpec{{Name: "unit", Command: "go test ./..."}, {Command: "echo ok"}}
+	results := []types.CommandResult{
+		{Command: "go test ./...", Stdout: long},
+		{Command: "echo ok", Stdout: "ok\n"},
+	}
+
+	save := func(index int, output string) (string, error) {
+		return logs.SaveFull(logDir, index, specs[index], output)
+	}
+
+	full := results
+	results, err := Results(results, specs, types.Flags{MaxOutputLines: 6}, save)
+	if err != nil {
+		t.Fatalf("Results() error = %v", err)
+	}
+
+	if full[0].Stdout != long || full[0].FullLog != "" {
+		t.Errorf("Original results should keep the full output, got %+v", full[0])
+	}
+
+	wantLog := filepath.Join(logDir, "1-unit.log")
+	if results[0].FullLog != wantLog {
+		t.Errorf("FullLog = %q, want %q", results[0].FullLog, wantLog)
+	}
+	if results[0].OmittedLines != 15 {
+		t.Errorf("OmittedLines = %d, want 15", results[0].OmittedLines)
+	}
+	if !strings.Contains(results[0].Stdout, "FAIL: expected 1") || !strings.Contains(results[0].Stdout, "full log at "+wantLog) {
+		t.Errorf("Truncated output should keep the error line and point to the log, got:\n%s", results[0].Stdout)
+	}
+
+	data, err := os.ReadFile(wantLog)
+	if err != nil || string(data) != long {
+		t.Errorf("Full log should contain the original output, got %q, %v", data, err)
+	}
+
+	if results[1].FullLog != "" || results[1].Stdout != "ok\n" {
+		t.Errorf("Short output should not be touched, got %+v", results[1])
+	}
+}
@@ -0,0 +1,339 @@
+package truncate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// charsPerToken - грубая оценка длины токена для английского текста и кода
+const charsPerToken = 4
+
+// keepFrames - сколько кадров стека подряд остается перед свертыванием
+const keepFrames = 5
+
+// Limits ограничивает вывод одной команды; 0 - без ограничения
+type Limits struct {
+	Lines  int
+	Tokens int
+}
+
+// Enabled проверяет, задано ли хотя бы одно ограничение
+func (l Limits) Enabled() bool {
+	return l.Lines > 0 || l.Tokens > 0
+}
+
+// EstimateTokens оценивает число токенов в тексте (~4 символа на токен)
+func EstimateTokens(text string) int {
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
+var (
+	errorLinePattern = regexp.MustCompile(`(?i)\b(error|errors|fail|failed|failure|failing|panic|exception|fatal|assert\w*)\b|✕|✗|×|●`)
+	jsFramePattern   = regexp.MustCompile(`^\s+at \S`)
+	pyFramePattern   = regexp.MustCompile(`^\s+File "[^"]+", line \d+`)
+	goFramePattern   = regexp.MustCompile(`^\t\S+\.go:\d+( \+0x[0-9a-f]+)?$`)
+)
+
+// entry - строка результата; свернутые повторы и кадры стека заменяются одной строкой-маркером
+type entry struct {
+	text   string
+	lines  int  // сколько строк исходного вывода представляет запись
+	marker bool // запись - маркер свертки, а не строка вывода
+	first  int  // индекс первой строки исходного вывода, которую представляет запись
+}
+
+// Output ограничивает stdout и stderr команды общим бюджетом limits. Бюджет делится между потоками:
+// поток, которому хватает половины, получает все, что ему нужно. Возвращает число скрытых строк
+func Output(stdout, stderr string, limits Limits, logPath string) (string, string, int) {
+	if fits(stdout+stderr, limits) {
+		return stdout, stderr, 0
+	}
+
+	stdoutLimits, stderrLimits := Limits{}, Limits{}
+	stdoutLimits.Lines, stderrLimits.Lines = split(limits.Lines, countLines(stdout), countLines(stderr))
+	stdoutLimits.Tokens, stderrLimits.Tokens = split(limits.Tokens, EstimateTokens(stdout), EstimateTokens(stderr))
+
+	stdout, stdoutOmitted := Text(stdout, stdoutLimits, logPath)
+	stderr, stderrOmitted := Text(stderr, stderrLimits, logPath)
+
+	return stdout, stderr, stdoutOmitted + stderrOmitted
+}
+
+// split делит бюджет между двумя потоками с размерами a и b
+func split(limit, a, b int) (int, int) {
+	switch {
+	case limit <= 0:
+		return 0, 0
+	case a+b <= limit:
+		return a, b
+	case a <= limit/2:
+		return max(a, 1), limit - a
+	case b <= limit/2:
+		return limit - b, max(b, 1)
+	default:
+		return max(limit/2, 1), max(limit-limit/2, 1)
+	}
+}
+
+// Text ограничивает текст: сворачивает повторяющиеся строки и длинные стеки, затем оставляет начало,
+// конец и строки с ошибками, а пропуски помечает "… N lines omitted (full log at path)".
+// Возвращает текст и число скрытых строк; текст в пределах лимитов возвращается без изменений
+func Text(text string, limits Limits, logPath string) (string, int) {
+	if fits(text, limits) {
+		return text, 0
+	}
+
+	entries, total := limitEntries(text, limits, logPath)
+
+	shown := 0
+	result := make([]string, len(entries))
+	for i, item := range entries {
+		result[i] = item.text
+		if !item.marker {
+			shown++
+		}
+	}
+
+	output := strings.Join(result, "\n")
+	if strings.HasSuffix(text, "\n") {
+		output += "\n"
+	}
+
+	return output, total - shown
+}
+
+// limitEntries делит текст на строки и оставляет из них то, что помещается в limits; возвращает записи
+// и исходное число строк
+func limitEntries(text string, limits Limits, logPath string) ([]entry, int) {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+
+	entries := collapse(lines)
+	if limits.Tokens > 0 {
+		entries = cutLongLines(entries, limits.Tokens*charsPerToken/8)
+	}
+
+	if !fitsEntries(entries, limits) {
+		entries = selectEntries(entries, limits, logPath)
+	}
+
+	return entries, len(lines)
+}
+
+// collapse сворачивает подряд идущие одинаковые строки и кадры стека сверх keepFrames
+func collapse(lines []string) []entry {
+	frames := frameMask(lines)
+	entries := []entry{}
+
+	for i := 0; i < len(lines); {
+		if frames[i] {
+			end := i
+			for end < len(lines) && frames[end] {
+				end++
+			}
+			for j, line := range lines[i:min(end, i+keepFrames)] {
+				entries = append(entries, entry{text: line, lines: 1, first: i + j})
+			}
+			if hidden := end - i - keepFrames; hidden > 0 {
+				entries = append(entries, entry{text: fmt.Sprintf("    … %d more stack frame lines", hidden), lines: hidden, marker: true, first: i + keepFrames})
+			}
+			i = end
+			continue
+		}
+
+		end := i + 1
+		for end < len(lines) && lines[end] == lines[i] && !frames[end] {
+			end++
+		}
+		repeated := end - i - 1
+		if strings.TrimSpace(lines[i]) == "" {
+			// Пустые строки подряд сворачиваются в одну без маркера
+			entries = append(entries, entry{text: lines[i], lines: repeated + 1, first: i})
+			i = end
+			continue
+		}
+		entries = append(entries, entry{text: lines[i], lines: 1, first: i})
+
+		switch {
+		case repeated == 0:
+		case repeated == 1:
+			entries = append(entries, entry{text: lines[i], lines: 1, first: i + 1})
+		default:
+			entries = append(entries, entry{text: fmt.Sprintf("… previous line repeated %d more times", repeated), lines: repeated, marker: true, first: i + 1})
+		}
+		i = end
+	}
+
+	return entries
+}
+
+// frameMask отмечает строки кадров стека JavaScript/Java ("at ..."), Python ("File ..." и строка кода)
+// и Go (функция и строка "\tfile.go:12 +0x1d")
+func frameMask(lines []string) []bool {
+	frames := make([]bool, len(lines))
+	for i, line := range lines {
+		switch {
+		case jsFramePattern.MatchString(line):
+			frames[i] = true
+		case pyFramePattern.MatchString(line):
+			frames[i] = true
+			if i+1 < len(lines) && indent(lines[i+1]) > indent(line) {
+				frames[i+1] = true
+			}
+		case goFramePattern.MatchString(line):
+			frames[i] = true
+			if i > 0 && !strings.HasPrefix(lines[i-1], "\t") && strings.HasSuffix(lines[i-1], ")") {
+				frames[i-1] = true
+			}
+		}
+	}
+	return frames
+}
+
+func indent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// cutLongLines обрезает строки длиннее limit символов, например минифицированный код
+func cutLongLines(entries []entry, limit int) []entry {
+	if limit <= 0 {
+		return entries
+	}
+	for i, item := range entries {
+		if !item.marker && len(item.text) > limit {
+			entries[i].text = fmt.Sprintf("%s … [%d chars omitted]", item.text[:limit], len(item.text)-limit)
+		}
+	}
+	return entries
+}
+
+// budget учитывает использованные строки и символы; нулевой максимум - без ограничения
+type budget struct {
+	maxLines int
+	maxChars int
+	lines    int
+	chars    int
+}
+
+func newBudget(lines, chars int) budget {
+	return budget{maxLines: lines, maxChars: chars}
+}
+
+func (b *budget) fits(text string) bool {
+	return (b.maxLines == 0 || b.lines+1 <= b.maxLines) && (b.maxChars == 0 || b.chars+len(text)+1 <= b.maxChars)
+}
+
+func (b *budget) add(text string) {
+	b.lines++
+	b.chars += len(text) + 1
+}
+
+// selectEntries оставляет четверть бюджета на начало, четверть на конец, остаток на строки с ошибками,
+// а неиспользованный остаток отдает концу вывода
+func selectEntries(entries []entry, limits Limits, logPath string) []entry {
+	chars := limits.Tokens * charsPerToken
+	total := newBudget(limits.Lines, chars)
+	quarter := func() budget {
+		return newBudget(quarterOf(limits.Lines), quarterOf(chars))
+	}
+	keep := make([]bool, len(entries))
+
+	keepEntry := func(i int, phase *budget) bool {
+		if keep[i] {
+			return true
+		}
+		text := entries[i].text
+		if (phase != nil && !phase.fits(text)) || !total.fits(text) {
+			return false
+		}
+		if phase != nil {
+			phase.add(text)
+		}
+		total.add(text)
+		keep[i] = true
+		return true
+	}
+
+	head := quarter()
+	for i := 0; i < len(entries) && keepEntry(i, &head); i++ {
+	}
+
+	tail := quarter()
+	for i := len(entries) - 1; i >= 0 && keepEntry(i, &tail); i-- {
+	}
+
+	for i, item := range entries {
+		if !keep[i] && !item.marker && errorLinePattern.MatchString(item.text) {
+			keepEntry(i, nil)
+		}
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !keep[i] && !keepEntry(i, nil) {
+			break
+		}
+	}
+
+	selected := []entry{}
+	omitted, first := 0, 0
+	flush := func() {
+		if omitted == 0 {
+			return
+		}
+		marker := fmt.Sprintf("… %d lines omitted", omitted)
+		if logPath != "" {
+			marker += fmt.Sprintf(" (full log at %s)", logPath)
+		}
+		selected = append(selected, entry{text: marker, lines: omitted, marker: true, first: first})
+		omitted = 0
+	}
+
+	for i, item := range entries {
+		if !keep[i] {
+			if omitted == 0 {
+				first = item.first
+			}
+			omitted += item.lines
+			continue
+		}
+		flush()
+		selected = append(selected, item)
+	}
+	flush()
+
+	return selected
+}
+
+// quarterOf возвращает четверть лимита, но не меньше 1, чтобы не превратить лимит в "без ограничения"
+func quarterOf(limit int) int {
+	if limit <= 0 {
+		return 0
+	}
+	return max(limit/4, 1)
+}
+
+func fits(text string, limits Limits) bool {
+	if limits.Lines > 0 && countLines(text) > limits.Lines {
+		return false
+	}
+	if limits.Tokens > 0 && EstimateTokens(text) > limits.Tokens {
+		return false
+	}
+	return true
+}
+
+func fitsEntries(entries []entry, limits Limits) bool {
+	lines := make([]string, len(entries))
+	for i, item := range entries {
+		lines[i] = item.text
+	}
+	return fits(strings.Join(lines, "\n"), limits)
+}
+
+// countLines возвращает число строк текста; завершающий перевод строки не добавляет строку
+func countLines(text string) int {
+	if text == "" {
+		return 0
+	}
+	return strings.Count(strings.TrimSuffix(text, "\n"), "\n") + 1
+}
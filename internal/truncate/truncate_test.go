@@ -0,0 +1,198 @@
+package truncate
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func numberedLines(from, to int) []string {
+	lines := []string{}
+	for i := from; i <= to; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	return lines
+}
+
+func TestText_WithinLimits(t *testing.T) {
+	text := "a\nb\nc\n"
+
+	got, omitted := Text(text, Limits{Lines: 3, Tokens: 100}, "log")
+	if got != text || omitted != 0 {
+		t.Errorf("Text() = %q, %d, want unchanged text", got, omitted)
+	}
+
+	got, omitted = Text(text, Limits{}, "log")
+	if got != text || omitted != 0 {
+		t.Errorf("Text() without limits = %q, %d, want unchanged text", got, omitted)
+	}
+}
+
+func TestText_HeadTailAndErrors(t *testing.T) {
+	lines := numberedLines(1, 100)
+	lines[49] = "Error: something broke at line 50"
+	text := strings.Join(lines, "\n") + "\n"
+
+	got, omitted := Text(text, Limits{Lines: 12}, ".aifr/logs/1-test.log")
+
+	for _, want := range []string{"line 1\n", "line 3\n", "Error: something broke at line 50\n", "line 100\n", "full log at .aifr/logs/1-test.log"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected truncated text to contain %q, got:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "line 20\n") {
+		t.Errorf("Middle lines without errors should be omitted, got:\n%s", got)
+	}
+	if !strings.HasSuffix(got, "\n") {
+		t.Errorf("Trailing newline should be preserved, got %q", got)
+	}
+
+	shown := 0
+	for _, line := range strings.Split(strings.TrimSuffix(got, "\n"), "\n") {
+		if !strings.HasPrefix(line, "… ") {
+			shown++
+		}
+	}
+	if shown != 12 {
+		t.Errorf("Expected 12 kept lines, got %d:\n%s", shown, got)
+	}
+	if omitted != 88 {
+		t.Errorf("Expected 88 omitted lines, got %d", omitted)
+	}
+}
+
+func TestText_OmittedMarkerCounts(t *testing.T) {
+	text := strings.Join(numberedLines(1, 20), "\n")
+
+	got, _ := Text(text, Limits{Lines: 4}, "")
+
+	want := "line 1\n… 16 lines omitted\nline 18\nline 19\nline 20"
+	if got != want {
+		t.Errorf("Text() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestText_CollapsesRepeatedLines(t *testing.T) {
+	lines := append([]string{"start"}, strings.Split(strings.Repeat("retrying connection\n", 50), "\n")...)
+	lines = append(lines[:len(lines)-1], "", "", "", "done")
+	text := strings.Join(lines, "\n")
+
+	got, omitted := Text(text, Limits{Lines: 10}, "")
+
+	want := "start\nretrying connection\n… previous line repeated 49 more times\n\ndone"
+	if got != want {
+		t.Errorf("Text() =\n%q\nwant\n%q", got, want)
+	}
+	if omitted != 51 {
+		t.Errorf("Expected 51 omitted lines, got %d", omitted)
+	}
+}
+
+func TestText_CollapsesStackFrames(t *testing.T) {
+	lines := []string{"TypeError: Cannot read properties of undefined"}
+	for i := 1; i <= 30; i++ {
+		lines = append(lines, fmt.Sprintf("    at fn%d (src/file.js:%d:1)", i, i))
+	}
+	lines = append(lines, "Node.js v20.0.0")
+	text := strings.Join(lines, "\n")
+
+	got, _ := Text(text, Limits{Lines: 10}, "")
+
+	if !strings.Contains(got, "at fn5 (src/file.js:5:1)\n    … 25 more stack frame lines\n") {
+		t.Errorf("Expected stack frames to be collapsed after %d frames, got:\n%s", keepFrames, got)
+	}
+	if strings.Contains(got, "fn6") {
+		t.Errorf("Frames after the first %d should be hidden, got:\n%s", keepFrames, got)
+	}
+	if !strings.HasSuffix(got, "Node.js v20.0.0") {
+		t.Errorf("Tail should be kept, got:\n%s", got)
+	}
+}
+
+func TestText_GoAndPythonFrames(t *testing.T) {
+	goStack := "panic: boom\n\ngoroutine 1 [running]:\n"
+	for i := 1; i <= 10; i++ {
+		goStack += fmt.Sprintf("main.f%d(...)\n\t/app/main.go:%d +0x1d\n", i, i)
+	}
+	got, _ := Text(goStack, Limits{Lines: 12}, "")
+	if !strings.Contains(got, "… 15 more stack frame lines") {
+		t.Errorf("Expected Go frames to be collapsed, got:\n%s", got)
+	}
+
+	pyStack := "Traceback (most recent call last):\n"
+	for i := 1; i <= 10; i++ {
+		pyStack += fmt.Sprintf("  File \"app.py\", line %d, in f%d\n    f%d()\n", i, i, i+1)
+	}
+	pyStack += "RecursionError: maximum recursion depth exceeded\n"
+	got, _ = Text(pyStack, Limits{Lines: 12}, "")
+	if !strings.Contains(got, "… 15 more stack frame lines") || !strings.Contains(got, "RecursionError") {
+		t.Errorf("Expected Python frames to be collapsed, got:\n%s", got)
+	}
+}
+
+func TestText_Tokens(t *testing.T) {
+	text := strings.Join(numberedLines(1, 1000), "\n")
+
+	got, omitted := Text(text, Limits{Tokens: 100}, "")
+
+	if EstimateTokens(got) > 120 {
+		t.Errorf("Truncated text should stay close to the token budget, got %d tokens", EstimateTokens(got))
+	}
+	if omitted == 0 || !strings.Contains(got, "lines omitted") {
+		t.Errorf("Expected lines to be omitted, got:\n%s", got)
+	}
+}
+
+func TestText_LongLine(t *testing.T) {
+	text := strings.Repeat("x", 10000)
+
+	got, _ := Text(text, Limits{Tokens: 100}, "")
+
+	if !strings.Contains(got, "chars omitted]") || len(got) > 400 {
+		t.Errorf("Expected a long line to be cut, got %d chars: %s", len(got), got)
+	}
+}
+
+func TestOutput_SplitsBudget(t *testing.T) {
+	stdout := strings.Join(numberedLines(1, 100), "\n") + "\n"
+	stderr := "warning: small\n"
+
+	gotStdout, gotStderr, omitted := Output(stdout, stderr, Limits{Lines: 10}, "")
+
+	if gotStderr != stderr {
+		t.Errorf("Small stream should be kept whole, got %q", gotStderr)
+	}
+	if countLines(gotStdout) > 10 {
+		t.Errorf("Large stream should get the rest of the budget, got:\n%s", gotStdout)
+	}
+	if omitted != 91 {
+		t.Errorf("Expected 91 omitted lines, got %d", omitted)
+	}
+
+	gotStdout, gotStderr, omitted = Output("ok\n", "fine\n", Limits{Lines: 10}, "")
+	if gotStdout != "ok\n" || gotStderr != "fine\n" || omitted != 0 {
+		t.Errorf("Output within limits should be unchanged, got %q, %q, %d", gotStdout, gotStderr, omitted)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		limit, a, b int
+		wantA       int
+		wantB       int
+	}{
+		{0, 10, 10, 0, 0},
+		{20, 5, 10, 5, 10},
+		{20, 5, 100, 5, 15},
+		{20, 100, 3, 17, 3},
+		{20, 100, 100, 10, 10},
+		{1, 100, 100, 1, 1},
+	}
+
+	for _, tt := range tests {
+		a, b := split(tt.limit, tt.a, tt.b)
+		if a != tt.wantA || b != tt.wantB {
+			t.Errorf("split(%d, %d, %d) = %d, %d, want %d, %d", tt.limit, tt.a, tt.b, a, b, tt.wantA, tt.wantB)
+		}
+	}
+}
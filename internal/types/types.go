@@ -1,23 +1,188 @@
 package types
 
-import "time"
+import (
+	"strings"
+	"time"
+)
+
+// FailureKind описывает причину неуспешного завершения команды
+type FailureKind string
+
+const (
+	FailureNone      FailureKind = ""
+	FailureStart     FailureKind = "start"     // команду не удалось запустить (например, бинарник не найден)
+	FailureExit      FailureKind = "exit"      // команда завершилась с ненулевым кодом
+	FailureSignal    FailureKind = "signal"    // команда убита сигналом
+	FailureTimeout   FailureKind = "timeout"   // истек таймаут команды
+	FailureCancelled FailureKind = "cancelled" // выполнение отменено пользователем
+	FailureSkipped   FailureKind = "skipped"   // команда не была запущена
+)
 
 // CommandResult представляет результат выполнения команды
 type CommandResult struct {
-	Command   string
-	Duration  time.Duration
-	IsSuccess bool
-	Stdout    string
-	Stderr    string
+	Command         string
+	ResolvedCommand string // команда после разрешения npm скриптов, которая реально выполнялась
+	Shell           bool   // команда выполнялась через shell
+	Duration        time.Duration
+	IsSuccess       bool
+	Stdout          string
+	Stderr          string
+	ExitCode        int    // -1, если процесс не завершился сам
+	Signal          string // имя сигнала, завершившего процесс, например "SIGKILL"
+	FailureKind     FailureKind
+	Reason          string // подробности причины ошибки, например какой таймаут истек
+	StartedAt       time.Time
+	FinishedAt      time.Time
+	Attempts        []Attempt // все попытки, если команда повторялась; nil - была одна попытка
+	OmittedLines    int       // сколько строк вывода скрыто ограничением --max-output-lines/--max-output-tokens
+	FullLog         string    // путь к полному выводу, если вывод был обрезан
+	Logs            *LogFiles // файлы логов команды при --log-dir; nil - логи не сохранялись
+	OutputError     string    // ошибка чтения stdout/stderr; вывод команды может быть неполным
+	Dir             string    // рабочая директория команды, пустая строка - текущая директория aifr
+	Env             []string  // имена переменных окружения, заданных команде явно; значения не сохраняются
+	EnvFiles        []string  // .env файлы, загруженные в окружение команды
+	EnvAllow        []string  // переменные, унаследованные при очищенном окружении
+	CleanEnv        bool      // окружение aifr не наследовалось, кроме PATH и EnvAllow
+	// Output - stdout и stderr вместе в порядке чтения: внутри потока порядок точный, между потоками
+	// приблизительный. Stdout и Stderr содержат те же данные по потокам
+	Output []OutputChunk
+}
+
+// OutputChunk - кусок вывода команды из одного потока; соседние куски одного потока объединяются
+type OutputChunk struct {
+	Stream string    // "stdout" или "stderr"
+	Time   time.Time // когда кусок начал поступать
+	Text   string
+}
+
+// CombinedOutput возвращает stdout и stderr в порядке чтения; без Output - stdout, затем stderr
+func (r CommandResult) CombinedOutput() string {
+	if len(r.Output) == 0 {
+		if r.Stdout != "" && r.Stderr != "" && !strings.HasSuffix(r.Stdout, "\n") {
+			return r.Stdout + "\n" + r.Stderr
+		}
+		return r.Stdout + r.Stderr
+	}
+
+	var b strings.Builder
+	for _, chunk := range r.Output {
+		b.WriteString(chunk.Text)
+	}
+	return b.String()
+}
+
+// LogFiles содержит пути к логам команды, сохраненным при --log-dir
+type LogFiles struct {
+	Stdout   string
+	Stderr   string
+	Combined string // stdout и stderr в порядке чтения со временем каждой строки
+}
+
+// Attempt описывает одну попытку выполнения команды при --retries
+type Attempt struct {
+	Duration    time.Duration
+	ExitCode    int
+	Signal      string
+	FailureKind FailureKind
+}
+
+// PassedAfterRetry проверяет, прошла ли команда только после повторной попытки
+func (r CommandResult) PassedAfterRetry() bool {
+	return r.IsSuccess && len(r.Attempts) > 1
+}
+
+// ShellMode определяет, запускается ли команда через shell
+type ShellMode string
+
+const (
+	ShellAuto   ShellMode = ""       // shell используется, если в команде есть метасимволы
+	ShellAlways ShellMode = "always" // команда всегда выполняется через shell
+	ShellNever  ShellMode = "never"  // команда всегда выполняется напрямую
+)
+
+// CommandSpec описывает команду вместе с её индивидуальными опциями
+type CommandSpec struct {
+	Name    string // имя для ссылок из зависимостей, пустая строка - ссылаться по Command
+	Command string
+	Shell   ShellMode
+	Timeout time.Duration // 0 - используется Flags.CommandTimeout
+	Needs   []string      // имена команд, которые должны успешно завершиться до запуска этой
+	Dir     string        // рабочая директория команды, пустая строка - текущая
+	Env     []string      // дополнительные переменные окружения в формате KEY=VALUE
+	// EnvFiles - .env файлы, загружаемые в окружение команды по порядку; явные Env перекрывают их значения
+	EnvFiles []string
+	// EnvAllow и CleanEnv ограничивают наследуемое окружение: наследуются только PATH и переменные из EnvAllow
+	EnvAllow []string
+	CleanEnv bool
+	Retries  *int // число повторов при ошибке, nil - используется Flags.Retries
+	// MaxOutputLines и MaxOutputTokens ограничивают вывод команды в отчете, nil - используются значения из Flags
+	MaxOutputLines  *int
+	MaxOutputTokens *int
+}
+
+// Ref возвращает имя, по которому на команду ссылаются зависимости
+func (s CommandSpec) Ref() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Command
+}
+
+// CommandPlan описывает, как команда будет запущена, без ее выполнения (--dry-run)
+type CommandPlan struct {
+	Order           int // порядок допуска к слотам выполнения, начиная с 1
+	Stage           int // глубина в графе зависимостей, 0 - команда без зависимостей
+	Name            string
+	Command         string
+	ResolvedCommand string
+	Resolution      string   // откуда взялась команда, например npm скрипт и lock-файл пакетного менеджера
+	Executable      string   // путь к исполняемому файлу, пустая строка - не найден
+	Args            []string // argv после разбора shellwords или вызова shell
+	Shell           bool
+	Dir             string
+	Env             []string // имена дополнительных переменных окружения поверх текущего окружения
+	EnvFiles        []string // .env файлы, загружаемые перед Env
+	EnvAllow        []string // переменные, наследуемые при очищенном окружении
+	CleanEnv        bool
+	Timeout         time.Duration
+	Needs           []string
+	Error           string // ошибка, из-за которой команда не запустится
 }
 
 // Flags содержит флаги CLI
 type Flags struct {
-	Output      string // "none", "errors", "full"
-	ShowSummary bool
-	ShowTime    bool
-	Stream      bool
-	Threads     int
+	Output          string // "none", "errors", "full"
+	Format          string // "text", "xml", "json", "ndjson"
+	ReportFile      string // путь для JSON отчета, пустая строка - не сохранять
+	JUnitFile       string // путь для JUnit XML отчета, пустая строка - не сохранять
+	Shell           bool
+	ShowSummary     bool
+	ShowTime        bool
+	Stream          bool
+	Timestamps      bool // добавлять к строкам --stream время от начала запуска
+	Threads         int
+	Timeout         time.Duration // общий лимит времени на весь запуск, 0 - без ограничения
+	CommandTimeout  time.Duration // лимит времени на одну команду, 0 - без ограничения
+	GracePeriod     time.Duration // время между SIGINT, SIGTERM и SIGKILL, 0 - сразу SIGKILL
+	MaxFailures     int           // после скольких ошибок остановить запуск, 0 - выполнить все команды
+	DryRun          bool          // показать план запуска без выполнения команд
+	Retries         int           // сколько раз повторить упавшую команду
+	RetryBackoff    time.Duration // пауза перед первым повтором, удваивается с каждой попыткой
+	Repeat          int           // сколько раз повторить весь набор команд (--repeat), 0 - обычный запуск
+	MaxOutputLines  int           // лимит строк вывода одной команды в отчете, 0 - без ограничения
+	MaxOutputTokens int           // лимит токенов вывода одной команды в отчете, 0 - без ограничения
+	LogDir          string        // директория логов команд (--log-dir), пустая строка - не сохранять
+	KeepLogs        int           // сколько последних запусков хранить в LogDir, 0 - все
+}
+
+// RunInfo содержит метаданные запуска для отчетов
+type RunInfo struct {
+	Version    string
+	Cwd        string
+	Threads    int
+	StartedAt  time.Time
+	FinishedAt time.Time
+	LogDir     string // директория логов этого запуска при --log-dir
 }
 
 // RunnerOptions содержит опции для запуска команд
@@ -5,24 +5,62 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"sort"
 	"syscall"
+	"time"
 
+	"github.com/CyberWalrus/ai-friendly-runner/internal/config"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/discovery"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/events"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/executor"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/logs"
 	"github.com/CyberWalrus/ai-friendly-runner/internal/reporter"
 	"github.com/CyberWalrus/ai-friendly-runner/internal/runner"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/spec"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/state"
+	"github.com/CyberWalrus/ai-friendly-runner/internal/truncate"
 	"github.com/CyberWalrus/ai-friendly-runner/internal/types"
 	"github.com/spf13/cobra"
 )
 
 var (
-	output    string
-	noTime    bool
-	noSummary bool
-	stream    bool
-	threads   int
-	showHelp  bool
-	version   = "dev"
+	output         string
+	format         string
+	reportFile     string
+	junitFile      string
+	eventsFD       int
+	shell          bool
+	noTime         bool
+	noSummary      bool
+	stream         bool
+	threads        int
+	timeout        time.Duration
+	commandTimeout time.Duration
+	gracePeriod    time.Duration
+	failFast       bool
+	maxFailures    int
+	configPath     string
+	listJSON       bool
+	dryRun         bool
+	rerunFailed    bool
+	retries        int
+	retryBackoff   time.Duration
+	repeat         int
+	maxLines       int
+	maxTokens      int
+	logDirFlag     string
+	noTUI          bool
+	timestamps     bool
+	keepLogs       int
+	lastFormat     string
+	showHelp       bool
+	version        = "dev"
 )
 
+// logDir - директория полных логов команд, вывод которых обрезан в отчете
+var logDir = filepath.Join(state.Dir, "logs")
+
 var rootCmd = &cobra.Command{
 	Use:   "aifr [flags] <command1> <command2> ...",
 	Short: "Parallel npm script runner with AI-optimized output",
@@ -40,7 +78,57 @@ Built with Go for minimal overhead and maximum performance.`,
   aifr --stream build test
 
   # Control parallelism
-  aifr --threads 4 lint test typecheck`,
+  aifr --threads 4 lint test typecheck
+
+  # Pipes, redirects and compound commands run through the shell
+  aifr "cd web && yarn build" "go test ./... | tee test.log"
+
+  # Force shell mode for a single command
+  aifr "[shell]make check"
+
+  # Run test and e2e only after build succeeds
+  aifr build test e2e "build -> test,e2e"
+
+  # See what can be run here
+  aifr list --json
+
+  # Show how commands resolve and in which order they start, without running them
+  aifr --dry-run lint test "build -> e2e"
+
+  # Repeat only what failed last time, or print the last report again
+  aifr rerun --failed
+  aifr last
+
+  # Retry flaky e2e tests up to twice, waiting 1s then 2s
+  aifr --retry-backoff 1s "[retries=2]test:e2e" lint
+
+  # Chase a flaky test: run it 50 times, 4 at a time
+  aifr --repeat 50 --threads 4 test:e2e
+
+  # Keep the report small enough for an agent's context window
+  aifr --max-output-lines 200 "[max-tokens=4000]test" lint
+
+  # Keep full stdout, stderr and timestamped logs of the last 5 runs
+  aifr --log-dir .aifr/runs --keep-logs 5 lint test
+
+  # Run the "ci" preset from aifr.yaml
+  aifr ci
+
+  # Stop everything as soon as one command fails
+  aifr --fail-fast typecheck lint test:e2e
+
+  # Machine-readable reports
+  aifr --format xml --output full lint test
+  aifr --format json lint test
+  aifr --report-file .aifr/report.json lint test
+  aifr --junit reports/junit.xml "go test -json ./..." test
+
+  # Live NDJSON events for supervising agents
+  aifr --format ndjson lint test
+  aifr --events-fd 3 lint test 3>events.ndjson
+
+  # Bound the whole run and each command
+  aifr --timeout 10m --command-timeout 2m lint "[timeout=30s]test:e2e"`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: run,
 }
@@ -53,41 +141,353 @@ var versionCmd = &cobra.Command{
 	},
 }
 
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List runnable tasks: config tasks, package.json scripts, Makefile targets and node_modules/.bin",
+	Example: `  aifr list
+  aifr list --json`,
+	Args: cobra.NoArgs,
+	RunE: runList,
+}
+
+var rerunCmd = &cobra.Command{
+	Use:   "rerun",
+	Short: "Run the commands of the last run again with the same flags",
+	Example: `  aifr rerun
+  aifr rerun --failed`,
+	Args: cobra.NoArgs,
+	RunE: runRerun,
+}
+
+var lastCmd = &cobra.Command{
+	Use:   "last",
+	Short: "Print the report of the last run without executing anything",
+	Example: `  aifr last
+  aifr last --format json`,
+	Args: cobra.NoArgs,
+	RunE: runLast,
+}
+
 func init() {
 	rootCmd.Flags().StringVarP(&output, "output", "o", "errors", "Output format: none | errors | full")
+	rootCmd.Flags().StringVarP(&format, "format", "f", "text", "Report format: text | xml | json | ndjson (live event stream)")
+	rootCmd.Flags().StringVar(&reportFile, "report-file", "", "Also write a JSON report to this file")
+	rootCmd.Flags().StringVar(&junitFile, "junit", "", "Also write a JUnit XML report to this file")
+	rootCmd.Flags().IntVar(&eventsFD, "events-fd", 0, "Also write NDJSON events to this file descriptor, e.g. 3")
 	rootCmd.Flags().BoolVarP(&noTime, "no-time", "t", false, "Hide execution time")
 	rootCmd.Flags().BoolVarP(&noSummary, "no-summary", "s", false, "Hide final summary")
 	rootCmd.Flags().BoolVarP(&stream, "stream", "w", false, "Enable streaming output with prefixes")
+	rootCmd.Flags().BoolVar(&timestamps, "timestamps", false, "Prefix --stream lines with the time since the run started")
+	rootCmd.Flags().BoolVar(&noTUI, "no-tui", false, "Disable the live command table (also off when stdout is not a terminal or CI is set)")
+	rootCmd.Flags().BoolVar(&shell, "shell", false, "Run every command through $SHELL -c (auto-detected for pipes, redirects and builtins)")
 	rootCmd.Flags().IntVarP(&threads, "threads", "n", runner.GetDefaultThreads(), "Number of parallel threads")
 
+	rootCmd.Flags().DurationVar(&timeout, "timeout", 0, "Global wall-clock budget for the whole run, e.g. 10m (0 = unlimited)")
+	rootCmd.Flags().DurationVar(&commandTimeout, "command-timeout", 0, "Timeout for each command, e.g. 2m (0 = unlimited)")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Path to aifr.yaml, aifr.json or package.json (default: searched upwards from cwd)")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print how each command would be resolved and scheduled without running anything")
+	rootCmd.Flags().IntVar(&retries, "retries", 0, "Retry a failed command up to N times; commands that pass on retry are reported as flaky")
+	rootCmd.Flags().DurationVar(&retryBackoff, "retry-backoff", 0, "Delay before the first retry, doubled for each next attempt, e.g. 1s")
+	rootCmd.Flags().IntVar(&repeat, "repeat", 0, "Run the commands N times in parallel up to --threads and report pass rate, durations and distinct failures")
+	rootCmd.Flags().IntVar(&maxLines, "max-output-lines", 0, "Limit each command's output in the report to N lines, keeping head, tail and error lines (0 = unlimited)")
+	rootCmd.Flags().IntVar(&maxTokens, "max-output-tokens", 0, "Limit each command's output in the report to about N tokens (0 = unlimited)")
+	rootCmd.Flags().StringVar(&logDirFlag, "log-dir", "", "Save each command's stdout, stderr and a combined timestamped log under a per-run directory here")
+	rootCmd.Flags().IntVar(&keepLogs, "keep-logs", 10, "How many runs to keep in --log-dir, oldest are removed (0 = keep all)")
+	rootCmd.Flags().BoolVar(&failFast, "fail-fast", false, "Stop all commands after the first failure")
+	rootCmd.Flags().IntVar(&maxFailures, "max-failures", 0, "Stop all commands after N failures (0 = run everything)")
+	rootCmd.Flags().DurationVar(&gracePeriod, "grace-period", 5*time.Second, "Time between SIGINT, SIGTERM and SIGKILL when a command is stopped")
+
+	listCmd.Flags().BoolVar(&listJSON, "json", false, "Print the list as JSON")
+
+	rerunCmd.Flags().BoolVar(&rerunFailed, "failed", false, "Rerun only commands that failed, were skipped or cancelled")
+	lastCmd.Flags().StringVarP(&lastFormat, "format", "f", "text", "Report format: text | xml | json")
+
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(rerunCmd)
+	rootCmd.AddCommand(lastCmd)
 }
 
 func run(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if cfg != nil {
+		var configFlags map[string]interface{}
+		args, configFlags = cfg.Expand(args)
+		if err := applyConfigFlags(cmd, cfg, configFlags); err != nil {
+			return err
+		}
+	}
+
 	validOutputs := map[string]bool{"none": true, "errors": true, "full": true}
 	if !validOutputs[output] {
 		return fmt.Errorf("invalid output format: %s (valid: none, errors, full)", output)
 	}
 
+	validFormats := map[string]bool{"text": true, "xml": true, "json": true, "ndjson": true}
+	if !validFormats[format] {
+		return fmt.Errorf("invalid report format: %s (valid: text, xml, json, ndjson)", format)
+	}
+
+	if dryRun && format != "text" && format != "json" {
+		return fmt.Errorf("--dry-run supports only --format text or json")
+	}
+
+	if format != "text" && stream {
+		return fmt.Errorf("--stream cannot be combined with --format %s", format)
+	}
+
+	if timestamps && !stream {
+		return fmt.Errorf("--timestamps requires --stream")
+	}
+
+	if eventsFD < 0 || eventsFD == 1 || eventsFD == 2 {
+		return fmt.Errorf("--events-fd must be a descriptor other than stdout/stderr, got: %d", eventsFD)
+	}
+
 	if threads < 1 {
 		return fmt.Errorf("threads must be >= 1, got: %d", threads)
 	}
 
-	ctx := cmd.Context()
+	if timeout < 0 || commandTimeout < 0 || gracePeriod < 0 {
+		return fmt.Errorf("timeouts must be >= 0")
+	}
+
+	if maxFailures < 0 {
+		return fmt.Errorf("max-failures must be >= 0, got: %d", maxFailures)
+	}
+
+	if retries < 0 || retryBackoff < 0 {
+		return fmt.Errorf("retries and retry-backoff must be >= 0")
+	}
+
+	if maxLines < 0 || maxTokens < 0 {
+		return fmt.Errorf("max-output-lines and max-output-tokens must be >= 0")
+	}
+
+	if keepLogs < 0 {
+		return fmt.Errorf("keep-logs must be >= 0, got: %d", keepLogs)
+	}
+
+	if repeat < 0 {
+		return fmt.Errorf("repeat must be >= 0, got: %d", repeat)
+	}
+
+	if repeat > 0 && (format == "xml" || junitFile != "") {
+		return fmt.Errorf("--repeat supports only --format text, json or ndjson and cannot write --junit")
+	}
+
+	if failFast && maxFailures == 0 {
+		maxFailures = 1
+	}
+
+	specs, err := spec.ParseAll(args)
+	if err != nil {
+		return err
+	}
+
+	if cfg != nil {
+		specs, err = cfg.ApplyTasks(specs)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := runner.ValidateDependencies(specs); err != nil {
+		return err
+	}
 
 	flags := types.Flags{
-		Output:      output,
-		ShowSummary: !noSummary,
-		ShowTime:    !noTime,
-		Stream:      stream,
-		Threads:     threads,
+		Output:          output,
+		Format:          format,
+		ReportFile:      reportFile,
+		JUnitFile:       junitFile,
+		Shell:           shell,
+		ShowSummary:     !noSummary,
+		ShowTime:        !noTime,
+		Stream:          stream,
+		Timestamps:      timestamps,
+		Threads:         threads,
+		Timeout:         timeout,
+		CommandTimeout:  commandTimeout,
+		GracePeriod:     gracePeriod,
+		MaxFailures:     maxFailures,
+		DryRun:          dryRun,
+		Retries:         retries,
+		RetryBackoff:    retryBackoff,
+		Repeat:          repeat,
+		MaxOutputLines:  maxLines,
+		MaxOutputTokens: maxTokens,
+		LogDir:          logDirFlag,
+		KeepLogs:        keepLogs,
+	}
+
+	if flags.DryRun {
+		return printPlan(specs, flags)
+	}
+
+	if flags.Repeat > 0 {
+		return executeRepeat(cmd.Context(), specs, flags)
+	}
+
+	return execute(cmd.Context(), specs, flags)
+}
+
+// withEventBus подключает к контексту шину событий с подписчиками из флагов и handlers; close закрывает --events-fd.
+// labels - подписи команд по индексу событий для --stream
+func withEventBus(ctx context.Context, flags types.Flags, labels []string, handlers ...events.Handler) (context.Context, func(), error) {
+	bus := events.NewBus()
+	for _, handler := range handlers {
+		bus.Subscribe(handler)
+	}
+	if flags.Stream {
+		bus.Subscribe(reporter.NewStreamWriter(os.Stdout, labels, flags.Timestamps).Handle)
+	}
+	if flags.Format == "ndjson" {
+		bus.Subscribe(reporter.NewNDJSONWriter(os.Stdout))
 	}
 
-	reporter.PrintRunning(args)
+	closeEvents := func() {}
+	if eventsFD > 0 {
+		eventsFile := os.NewFile(uintptr(eventsFD), "events")
+		if eventsFile == nil {
+			return nil, nil, fmt.Errorf("invalid --events-fd: %d", eventsFD)
+		}
+		closeEvents = func() { eventsFile.Close() }
+		bus.Subscribe(reporter.NewNDJSONWriter(eventsFile))
+	}
 
-	results := runner.RunCommands(ctx, args, flags)
+	return events.WithBus(ctx, bus), closeEvents, nil
+}
 
-	reporter.PrintReport(results, flags)
+// useDashboard проверяет, рисовать ли живую таблицу команд: только текстовый отчет без --stream
+// в интерактивном терминале вне CI
+func useDashboard(flags types.Flags) bool {
+	return !noTUI && flags.Format == "text" && !flags.Stream && reporter.IsInteractive(os.Stdout)
+}
+
+// commandLabels возвращает подписи команд для таблицы и --stream: имя команды, если оно задано, иначе сама команда
+func commandLabels(specs []types.CommandSpec) []string {
+	names := make([]string, len(specs))
+	for i, commandSpec := range specs {
+		names[i] = commandSpec.Ref()
+	}
+	return names
+}
+
+// openLogs создает директорию логов запуска, если задан --log-dir; names - базовые имена файлов команд
+func openLogs(flags types.Flags, names []string) (*logs.Writer, error) {
+	if flags.LogDir == "" {
+		return nil, nil
+	}
+	return logs.Open(flags.LogDir, flags.KeepLogs, names)
+}
+
+// finishLogs записывает index.json и пути логов в результаты; без --log-dir результаты не меняются
+func finishLogs(logWriter *logs.Writer, results []types.CommandResult, info *types.RunInfo) []types.CommandResult {
+	if logWriter == nil {
+		return results
+	}
+
+	info.LogDir = logWriter.Dir()
+	results, err := logWriter.Finish(results, *info)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	return results
+}
+
+// logSaver возвращает способ сохранить полный вывод обрезанной команды: при --log-dir это уже записанный
+// общий лог команды, иначе вывод сохраняется в dir
+func logSaver(dir string, specs []types.CommandSpec, results []types.CommandResult) truncate.Saver {
+	return func(index int, output string) (string, error) {
+		if files := results[index].Logs; files != nil {
+			return files.Combined, nil
+		}
+		return logs.SaveFull(dir, index, specs[index], output)
+	}
+}
+
+// truncateResults возвращает копию результатов для вывода в консоль с учетом лимитов вывода;
+// файлы отчетов и последний запуск сохраняются по исходным результатам с полным выводом
+func truncateResults(results []types.CommandResult, specs []types.CommandSpec, flags types.Flags, dir string) []types.CommandResult {
+	shown, err := truncate.Results(results, specs, flags, logSaver(dir, specs, results))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	return shown
+}
+
+// execute запускает команды, выводит отчеты, сохраняет последний запуск и завершает процесс с кодом 1 при ошибках
+func execute(ctx context.Context, specs []types.CommandSpec, flags types.Flags) error {
+	logWriter, err := openLogs(flags, logs.Names(specs))
+	if err != nil {
+		return err
+	}
+
+	handlers := []events.Handler{}
+	if logWriter != nil {
+		handlers = append(handlers, logWriter.Handle)
+	}
+
+	var dashboard *reporter.Dashboard
+	if useDashboard(flags) {
+		dashboard = reporter.NewTerminalDashboard(commandLabels(specs))
+		handlers = append(handlers, dashboard.Handle)
+	}
+
+	ctx, closeEvents, err := withEventBus(ctx, flags, commandLabels(specs), handlers...)
+	if err != nil {
+		return err
+	}
+	defer closeEvents()
+
+	switch {
+	case dashboard != nil:
+		dashboard.Start()
+		defer dashboard.Stop()
+	case flags.Format == "text":
+		reporter.PrintRunning(commandNames(specs))
+	}
+
+	cwd, _ := os.Getwd()
+	info := types.RunInfo{
+		Version:   version,
+		Cwd:       cwd,
+		Threads:   flags.Threads,
+		StartedAt: time.Now(),
+	}
+
+	results := runner.RunSpecs(ctx, specs, flags)
+	info.FinishedAt = time.Now()
+	if dashboard != nil {
+		dashboard.Stop()
+	}
+	results = finishLogs(logWriter, results, &info)
+
+	lastRun := state.LastRun{Info: info, Flags: flags, Specs: specs, Results: results}
+	if err := state.Save(".", lastRun); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to save last run: %v\n", err)
+	}
+
+	if err := printReport(truncateResults(results, specs, flags, logDir), flags, info); err != nil {
+		return err
+	}
+
+	if flags.ReportFile != "" {
+		if err := reporter.WriteJSONReportFile(flags.ReportFile, results, info); err != nil {
+			return err
+		}
+	}
+
+	if flags.JUnitFile != "" {
+		if err := reporter.WriteJUnitReportFile(flags.JUnitFile, results); err != nil {
+			return err
+		}
+	}
 
 	if !reporter.AllPassed(results) {
 		os.Exit(1)
@@ -96,10 +496,278 @@ func run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// Execute запускает CLI приложение с signal handling
+// executeRepeat запускает команды flags.Repeat раз и выводит статистику стабильности по каждой команде.
+// Последний запуск не сохраняется: rerun и last работают с обычными запусками
+func executeRepeat(ctx context.Context, specs []types.CommandSpec, flags types.Flags) error {
+	// Логи повторов различаются префиксом "r<N>-"; индексы событий идут по повторам подряд, как в RepeatSpecs
+	names := make([]string, 0, len(specs)*flags.Repeat)
+	for run := 1; run <= flags.Repeat; run++ {
+		for _, name := range logs.Names(specs) {
+			names = append(names, fmt.Sprintf("r%d-%s", run, name))
+		}
+	}
+
+	logWriter, err := openLogs(flags, names)
+	if err != nil {
+		return err
+	}
+
+	handlers := []events.Handler{}
+	if logWriter != nil {
+		handlers = append(handlers, logWriter.Handle)
+	}
+
+	// Подписи повторов различаются номером повтора, как имена в RepeatSpecs
+	labels := make([]string, 0, len(specs)*flags.Repeat)
+	for run := 1; run <= flags.Repeat; run++ {
+		for _, label := range commandLabels(specs) {
+			labels = append(labels, fmt.Sprintf("%s#%d", label, run))
+		}
+	}
+
+	ctx, closeEvents, err := withEventBus(ctx, flags, labels, handlers...)
+	if err != nil {
+		return err
+	}
+	defer closeEvents()
+
+	if flags.Format == "text" {
+		reporter.PrintRepeating(commandNames(specs), flags.Repeat)
+	}
+
+	cwd, _ := os.Getwd()
+	info := types.RunInfo{
+		Version:   version,
+		Cwd:       cwd,
+		Threads:   flags.Threads,
+		StartedAt: time.Now(),
+	}
+
+	runs := runner.RepeatSpecs(ctx, specs, flags, flags.Repeat)
+	info.FinishedAt = time.Now()
+
+	if logWriter != nil {
+		all := []types.CommandResult{}
+		for _, results := range runs {
+			all = append(all, results...)
+		}
+		all = finishLogs(logWriter, all, &info)
+		for i := range runs {
+			runs[i] = all[i*len(specs) : (i+1)*len(specs)]
+		}
+	}
+
+	shown := make([][]types.CommandResult, len(runs))
+	for i := range runs {
+		shown[i] = truncateResults(runs[i], specs, flags, filepath.Join(logDir, fmt.Sprintf("repeat-%d", i+1)))
+	}
+
+	switch flags.Format {
+	case "json":
+		if err := reporter.WriteStressJSON(os.Stdout, reporter.BuildStressStats(specs, shown), info); err != nil {
+			return err
+		}
+	case "text":
+		reporter.PrintStressReport(os.Stdout, reporter.BuildStressStats(specs, shown), flags)
+	}
+
+	if flags.ReportFile != "" {
+		if err := reporter.WriteStressJSONFile(flags.ReportFile, reporter.BuildStressStats(specs, runs), info); err != nil {
+			return err
+		}
+	}
+
+	for _, results := range runs {
+		if !reporter.AllPassed(results) {
+			os.Exit(1)
+		}
+	}
+
+	return nil
+}
+
+// printReport выводит отчет в формате flags.Format; ndjson уже выведен событиями во время запуска
+func printReport(results []types.CommandResult, flags types.Flags, info types.RunInfo) error {
+	switch flags.Format {
+	case "xml":
+		return reporter.PrintXMLReport(results, flags)
+	case "json":
+		return reporter.PrintJSONReport(results, info)
+	case "text":
+		reporter.PrintReport(results, flags)
+	}
+	return nil
+}
+
+func runRerun(cmd *cobra.Command, args []string) error {
+	lastRun, err := state.Load(".")
+	if err != nil {
+		return err
+	}
+
+	specs := lastRun.Specs
+	if rerunFailed {
+		specs = lastRun.FailedSpecs()
+		if len(specs) == 0 {
+			fmt.Println("All commands passed in the last run, nothing to rerun")
+			return nil
+		}
+	}
+
+	specs, err = restoreEnv(specs)
+	if err != nil {
+		return err
+	}
+
+	return execute(cmd.Context(), specs, lastRun.Flags)
+}
+
+func runLast(cmd *cobra.Command, args []string) error {
+	lastRun, err := state.Load(".")
+	if err != nil {
+		return err
+	}
+
+	flags := lastRun.Flags
+	if cmd.Flags().Changed("format") {
+		validFormats := map[string]bool{"text": true, "xml": true, "json": true}
+		if !validFormats[lastFormat] {
+			return fmt.Errorf("invalid report format: %s (valid: text, xml, json)", lastFormat)
+		}
+		flags.Format = lastFormat
+	}
+	// Событий прошлого запуска больше нет, поэтому ndjson выводится как JSON отчет
+	if flags.Format == "ndjson" {
+		flags.Format = "json"
+	}
+
+	if err := printReport(truncateResults(lastRun.Results, lastRun.Specs, flags, logDir), flags, lastRun.Info); err != nil {
+		return err
+	}
+
+	if !reporter.AllPassed(lastRun.Results) {
+		os.Exit(1)
+	}
+
+	return nil
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	entries, err := discovery.Discover(".", cfg)
+	if err != nil {
+		return err
+	}
+
+	if listJSON {
+		return reporter.WriteListJSON(os.Stdout, entries)
+	}
+
+	reporter.PrintList(os.Stdout, entries)
+	return nil
+}
+
+// printPlan выводит план запуска для --dry-run
+func printPlan(specs []types.CommandSpec, flags types.Flags) error {
+	plans, err := runner.PlanSpecs(specs, flags)
+	if err != nil {
+		return err
+	}
+
+	if flags.Format == "json" {
+		return reporter.WritePlanJSON(os.Stdout, plans, flags)
+	}
+
+	reporter.PrintPlan(os.Stdout, plans, flags)
+	return nil
+}
+
+// loadConfig загружает конфигурацию из --config или ищет ее, поднимаясь от текущей директории
+// restoreEnv возвращает командам rerun значения env: в last-run.json сохранены только имена переменных
+func restoreEnv(specs []types.CommandSpec) ([]types.CommandSpec, error) {
+	needsConfig := false
+	for _, spec := range specs {
+		needsConfig = needsConfig || len(spec.Env) > 0
+	}
+	if !needsConfig {
+		return specs, nil
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	restored := make([]types.CommandSpec, len(specs))
+	for i, spec := range specs {
+		if restored[i], err = cfg.RestoreEnv(spec); err != nil {
+			return nil, err
+		}
+	}
+	return restored, nil
+}
+
+func loadConfig() (*config.Config, error) {
+	if configPath != "" {
+		return config.Load(configPath)
+	}
+	return config.Discover(".")
+}
+
+// applyConfigFlags применяет значения флагов из конфигурации; явно переданные флаги CLI имеют приоритет
+func applyConfigFlags(cmd *cobra.Command, cfg *config.Config, values map[string]interface{}) error {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || name == "config" || name == "help" {
+			return fmt.Errorf("unknown flag %q in config %s", name, cfg.Path)
+		}
+		if flag.Changed {
+			continue
+		}
+		if err := cmd.Flags().Set(name, fmt.Sprint(values[name])); err != nil {
+			return fmt.Errorf("invalid value for flag %q in config %s: %w", name, cfg.Path, err)
+		}
+	}
+
+	return nil
+}
+
+func commandNames(specs []types.CommandSpec) []string {
+	names := make([]string, len(specs))
+	for i, commandSpec := range specs {
+		names[i] = commandSpec.Command
+	}
+	return names
+}
+
+// Execute запускает CLI приложение с signal handling:
+// первый Ctrl+C мягко останавливает команды, второй сразу убивает все процессы
 func Execute() error {
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	go func() {
+		<-signals
+		fmt.Fprintln(os.Stderr, "\nStopping commands... (press Ctrl+C again to force kill)")
+		cancel()
+
+		<-signals
+		executor.KillAll()
+	}()
+
 	return rootCmd.ExecuteContext(ctx)
 }
@@ -1,9 +1,12 @@
 package e2e_test
 
 import (
+	"encoding/json"
+	"encoding/xml"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -222,3 +225,708 @@ func TestNoCommands(t *testing.T) {
 		t.Error("Expected error when no commands provided")
 	}
 }
+
+func TestShellCommands(t *testing.T) {
+	cmd := exec.Command(binaryPath, "--output", "full", "echo piped | tr a-z A-Z", "FOO=bar sh -c 'echo $FOO'")
+	output, err := cmd.CombinedOutput()
+
+	if err != nil {
+		t.Fatalf("Shell commands failed: %v\nOutput: %s", err, output)
+	}
+
+	outputStr := string(output)
+
+	if !strings.Contains(outputStr, "PIPED") {
+		t.Error("Pipe should be executed through shell")
+	}
+	if !strings.Contains(outputStr, "bar") {
+		t.Error("Env prefix should be executed through shell")
+	}
+}
+
+func TestTimeoutFlag(t *testing.T) {
+	start := time.Now()
+	cmd := exec.Command(binaryPath, "--command-timeout", "200ms", "--grace-period", "100ms", "echo partial; sleep 5")
+	output, err := cmd.CombinedOutput()
+
+	if err == nil {
+		t.Error("Expected non-zero exit code for timed out command")
+	}
+
+	if time.Since(start) > 3*time.Second {
+		t.Errorf("Command timeout not enforced: %v", time.Since(start))
+	}
+
+	outputStr := string(output)
+
+	if !strings.Contains(outputStr, "timed out") {
+		t.Errorf("Output should mark command as timed out, got: %s", outputStr)
+	}
+	if !strings.Contains(outputStr, "partial") {
+		t.Error("Partial output should be preserved")
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	cmd := exec.Command(binaryPath, "--format", "json", "echo hello", "exit 2")
+	output, err := cmd.Output()
+
+	if err == nil {
+		t.Error("Expected non-zero exit code when a command fails")
+	}
+
+	var report struct {
+		Run struct {
+			Version string `json:"version"`
+			Threads int    `json:"threads"`
+		} `json:"run"`
+		Commands []struct {
+			Command  string `json:"command"`
+			ExitCode int    `json:"exit_code"`
+			Stdout   string `json:"stdout"`
+		} `json:"commands"`
+	}
+
+	if err := json.Unmarshal(output, &report); err != nil {
+		t.Fatalf("Output is not a single JSON document: %v\nOutput: %s", err, output)
+	}
+
+	if len(report.Commands) != 2 {
+		t.Fatalf("Expected 2 commands, got %d", len(report.Commands))
+	}
+
+	if report.Commands[0].Stdout != "hello\n" || report.Commands[1].ExitCode != 2 {
+		t.Errorf("Unexpected commands in report: %+v", report.Commands)
+	}
+}
+
+func TestReportFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	cmd := exec.Command(binaryPath, "--report-file", path, "echo hello")
+	output, err := cmd.CombinedOutput()
+
+	if err != nil {
+		t.Fatalf("Command failed: %v\nOutput: %s", err, output)
+	}
+
+	if !strings.Contains(string(output), "1/1 passed") {
+		t.Error("Console report should still be printed")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Report file not written: %v", err)
+	}
+
+	if !json.Valid(data) {
+		t.Error("Report file should contain valid JSON")
+	}
+}
+
+func TestNDJSONFormat(t *testing.T) {
+	cmd := exec.Command(binaryPath, "--format", "ndjson", "echo hello")
+	output, err := cmd.Output()
+
+	if err != nil {
+		t.Fatalf("Command failed: %v\nOutput: %s", err, output)
+	}
+
+	types := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		var event struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("Line is not valid JSON: %q", line)
+		}
+		types = append(types, event.Type)
+	}
+
+	want := "run_started,command_queued,command_started,output_line,command_finished,run_finished"
+	if strings.Join(types, ",") != want {
+		t.Errorf("Event sequence = %v, want %s", types, want)
+	}
+}
+
+func TestJUnitReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "junit.xml")
+	cmd := exec.Command(binaryPath, "--junit", path, "echo hello", "exit 1")
+	_ = cmd.Run()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("JUnit file not written: %v", err)
+	}
+
+	var report struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+	}
+	if err := xml.Unmarshal(data, &report); err != nil {
+		t.Fatalf("JUnit file is not valid XML: %v", err)
+	}
+
+	if report.Tests != 2 || report.Failures != 1 {
+		t.Errorf("Expected 2 tests with 1 failure, got %+v", report)
+	}
+}
+
+func TestJUnitReport_FullOutputWithLimits(t *testing.T) {
+	dir := t.TempDir()
+	junitPath := filepath.Join(dir, "junit.xml")
+	reportPath := filepath.Join(dir, "report.json")
+	goTest := `for i in $(seq 1 60); do echo "{\"Action\":\"pass\",\"Package\":\"app\",\"Test\":\"Test$i\",\"Elapsed\":0}"; done`
+	cmd := exec.Command(binaryPath, "--max-output-lines", "20", "--output", "full", "--junit", junitPath, "--report-file", reportPath, goTest)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("Command failed: %v\n%s", err, output)
+	}
+
+	if !strings.Contains(string(output), "lines omitted") {
+		t.Errorf("Console report should be truncated, got:\n%s", output)
+	}
+
+	data, err := os.ReadFile(junitPath)
+	if err != nil {
+		t.Fatalf("JUnit file not written: %v", err)
+	}
+	var junit struct {
+		Tests int `xml:"tests,attr"`
+	}
+	if err := xml.Unmarshal(data, &junit); err != nil {
+		t.Fatalf("JUnit file is not valid XML: %v", err)
+	}
+	if junit.Tests != 60 {
+		t.Errorf("JUnit report should count every test despite --max-output-lines, got tests=%d", junit.Tests)
+	}
+
+	data, err = os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("Report file not written: %v", err)
+	}
+	if !strings.Contains(string(data), "Test60") || strings.Contains(string(data), "lines omitted") {
+		t.Error("Report file should contain the full output")
+	}
+}
+
+func TestXMLFormat(t *testing.T) {
+	cmd := exec.Command(binaryPath, "--format", "xml", "echo ok", "echo '</x> <' && exit 1")
+	output, _ := cmd.Output()
+
+	var report struct {
+		Commands []struct {
+			Name   string `xml:"name,attr"`
+			Status string `xml:"status,attr"`
+			Exit   int    `xml:"exit,attr"`
+			Stdout string `xml:"stdout"`
+		} `xml:"command"`
+	}
+
+	if err := xml.Unmarshal(output, &report); err != nil {
+		t.Fatalf("Output is not well-formed XML: %v\nOutput: %s", err, output)
+	}
+
+	if len(report.Commands) != 2 {
+		t.Fatalf("Expected 2 commands, got %d", len(report.Commands))
+	}
+
+	failed := report.Commands[1]
+	if failed.Status != "failed" || failed.Exit != 1 || failed.Stdout != "</x> <\n" {
+		t.Errorf("Unexpected failed command: %+v", failed)
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	cmd := exec.Command(binaryPath, "--no-time", "[name=build]exit 1", "[name=deploy]echo deployed", "build -> deploy")
+	output, err := cmd.CombinedOutput()
+
+	if err == nil {
+		t.Error("Expected non-zero exit code when prerequisite fails")
+	}
+
+	outputStr := string(output)
+
+	if strings.Contains(outputStr, "\ndeployed") {
+		t.Error("Dependent command should not run after prerequisite failure")
+	}
+	if !strings.Contains(outputStr, `skipped: dependency "build" failed`) {
+		t.Errorf("Report should explain why command was skipped, got: %s", outputStr)
+	}
+}
+
+func TestFailFast(t *testing.T) {
+	start := time.Now()
+	cmd := exec.Command(binaryPath, "--no-time", "--fail-fast", "-n", "2", "--grace-period", "100ms", "[name=typecheck]exit 1", "sleep 5")
+	output, err := cmd.CombinedOutput()
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Error("Expected non-zero exit code with --fail-fast")
+	}
+
+	if elapsed > 3*time.Second {
+		t.Errorf("--fail-fast should stop running commands, took %v", elapsed)
+	}
+
+	if !strings.Contains(string(output), `run aborted after "typecheck" failed`) {
+		t.Errorf("Report should name the command that triggered the abort, got: %s", output)
+	}
+}
+
+func TestConfigPreset(t *testing.T) {
+	root := t.TempDir()
+	config := `
+flags:
+  no-time: true
+tasks:
+  greet:
+    command: echo "hello $AIFR_NAME from $(basename $PWD)"
+    cwd: web
+    env:
+      AIFR_NAME: config
+    needs: [prepare]
+  prepare: mkdir -p web
+presets:
+  check:
+    tasks: [greet]
+    flags:
+      output: full
+`
+	if err := os.WriteFile(filepath.Join(root, "aifr.yaml"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	subdir := filepath.Join(root, "packages", "app")
+	if err := os.MkdirAll(subdir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// prepare не входит в пресет, но добавляется как зависимость greet и создает web
+	cmd := exec.Command(binaryPath, "check")
+	cmd.Dir = subdir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Preset run failed: %v\n%s", err, output)
+	}
+
+	outputStr := string(output)
+	if !strings.Contains(outputStr, "hello config from web") {
+		t.Errorf("Task should run with config cwd and env, got: %s", outputStr)
+	}
+	if strings.Contains(outputStr, "ms)") {
+		t.Errorf("no-time from config flags should hide durations, got: %s", outputStr)
+	}
+
+	// Флаги CLI перекрывают значения из конфигурации
+	cmd = exec.Command(binaryPath, "--output", "none", "check")
+	cmd.Dir = subdir
+	output, _ = cmd.CombinedOutput()
+	if strings.Contains(string(output), "hello config") {
+		t.Errorf("--output none should override preset output, got: %s", output)
+	}
+}
+
+func TestListCommand(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"scripts": {"lint": "eslint ."}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Makefile"), []byte("build: ## Build it\n\tgo build\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(binaryPath, "list", "--json")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("aifr list --json failed: %v", err)
+	}
+
+	var entries []struct {
+		Name    string `json:"name"`
+		Source  string `json:"source"`
+		Command string `json:"command"`
+	}
+	if err := json.Unmarshal(output, &entries); err != nil {
+		t.Fatalf("Invalid JSON output: %v\n%s", err, output)
+	}
+
+	found := map[string]string{}
+	for _, entry := range entries {
+		found[entry.Source+":"+entry.Name] = entry.Command
+	}
+
+	if found["script:lint"] != "npm run lint" {
+		t.Errorf("Expected lint script resolved to npm, got: %s", output)
+	}
+	if found["make:build"] != "make build" {
+		t.Errorf("Expected make target build, got: %s", output)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "marker")
+
+	cmd := exec.Command(binaryPath, "--dry-run", "--format", "json", "-n", "1", "[name=second]echo done", "[name=first]touch "+marker, "first -> second")
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("--dry-run failed: %v", err)
+	}
+
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("--dry-run must not execute commands")
+	}
+
+	var plan struct {
+		Commands []struct {
+			Order int      `json:"order"`
+			Stage int      `json:"stage"`
+			Args  []string `json:"argv"`
+		} `json:"commands"`
+	}
+	if err := json.Unmarshal(output, &plan); err != nil {
+		t.Fatalf("Invalid JSON plan: %v\n%s", err, output)
+	}
+
+	if len(plan.Commands) != 2 {
+		t.Fatalf("Expected 2 planned commands, got: %s", output)
+	}
+	if plan.Commands[1].Stage != 1 || strings.Join(plan.Commands[1].Args, " ") != "echo done" {
+		t.Errorf("Dependent command should be planned second at stage 1, got: %s", output)
+	}
+}
+
+func TestRerunFailedAndLast(t *testing.T) {
+	dir := t.TempDir()
+	flaky := "[ -f marker ] || { touch marker; exit 1; }"
+
+	cmd := exec.Command(binaryPath, "--no-time", "echo stable", flaky)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err == nil {
+		t.Fatalf("First run should fail, got: %s", output)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".aifr", "last-run.json")); err != nil {
+		t.Fatalf("Last run should be saved: %v", err)
+	}
+
+	cmd = exec.Command(binaryPath, "rerun", "--failed")
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Rerun of the fixed command should pass: %v\n%s", err, output)
+	}
+	if strings.Contains(string(output), "echo stable") {
+		t.Errorf("Rerun --failed should skip passed commands, got: %s", output)
+	}
+	if !strings.Contains(string(output), "Summary: 1/1 passed") {
+		t.Errorf("Rerun should use the saved flags and report, got: %s", output)
+	}
+
+	cmd = exec.Command(binaryPath, "last", "--format", "json")
+	cmd.Dir = dir
+	output, err = cmd.Output()
+	if err != nil {
+		t.Fatalf("aifr last failed: %v", err)
+	}
+
+	var report struct {
+		Summary struct {
+			Total  int `json:"total"`
+			Passed int `json:"passed"`
+		} `json:"summary"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		t.Fatalf("Invalid JSON from aifr last: %v\n%s", err, output)
+	}
+	if report.Summary.Total != 1 || report.Summary.Passed != 1 {
+		t.Errorf("aifr last should print the rerun report, got: %s", output)
+	}
+}
+
+func TestRerunRestoresEnvFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	config := "tasks:\n  greet:\n    command: sh -c 'printf %s \"$GREETING\" > greeting.txt'\n    env:\n      GREETING: hello-secret\n"
+	if err := os.WriteFile(filepath.Join(dir, "aifr.yaml"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(binaryPath, "greet")
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("First run failed: %v\n%s", err, output)
+	}
+
+	state, err := os.ReadFile(filepath.Join(dir, ".aifr", "last-run.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(state), "hello-secret") {
+		t.Errorf("Env values should not be saved in last-run.json, got:\n%s", state)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "greeting.txt")); err != nil {
+		t.Fatal(err)
+	}
+	cmd = exec.Command(binaryPath, "rerun")
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Rerun failed: %v\n%s", err, output)
+	}
+	if greeting, _ := os.ReadFile(filepath.Join(dir, "greeting.txt")); string(greeting) != "hello-secret" {
+		t.Errorf("Rerun should restore env values from the config, got %q", greeting)
+	}
+}
+
+func TestRerunRefusesCLIEnv(t *testing.T) {
+	dir := t.TempDir()
+
+	cmd := exec.Command(binaryPath, "[env=TOKEN=secret]sh -c 'test -n \"$TOKEN\"'")
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("First run failed: %v\n%s", err, output)
+	}
+
+	cmd = exec.Command(binaryPath, "rerun")
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Rerun without the env value should fail, got: %s", output)
+	}
+	if !strings.Contains(string(output), "cannot restore env TOKEN") {
+		t.Errorf("Rerun should explain why it refused, got: %s", output)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	dir := t.TempDir()
+
+	cmd := exec.Command(binaryPath, "--no-time", "--retries", "2", "[ -f marker ] || { touch marker; exit 1; }")
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Flaky command should pass after retry: %v\n%s", err, output)
+	}
+
+	if !strings.Contains(string(output), "passed after retry (attempt 2)") {
+		t.Errorf("Report should mark the command as passed after retry, got: %s", output)
+	}
+
+	cmd = exec.Command(binaryPath, "--no-time", "[retries=1]exit 2")
+	output, err = cmd.CombinedOutput()
+	if err == nil {
+		t.Fatal("Command failing on every attempt should fail the run")
+	}
+	if !strings.Contains(string(output), "exit code 2, 2 attempts") {
+		t.Errorf("Report should show the number of attempts, got: %s", output)
+	}
+}
+
+func TestDependencyCycle(t *testing.T) {
+	cmd := exec.Command(binaryPath, "a -> b", "b -> a")
+	output, err := cmd.CombinedOutput()
+
+	if err == nil {
+		t.Error("Expected error for dependency cycle")
+	}
+	if !strings.Contains(string(output), "dependency cycle") {
+		t.Errorf("Expected cycle error, got: %s", output)
+	}
+}
+
+func TestRepeat(t *testing.T) {
+	dir := t.TempDir()
+
+	// Команда падает на каждом втором запуске: счетчик хранится в файле
+	flaky := `n=$(cat count 2>/dev/null || echo 0); n=$((n+1)); echo $n > count; [ $((n % 2)) -eq 1 ] || { echo "even run" >&2; exit 1; }`
+
+	cmd := exec.Command(binaryPath, "--no-time", "--repeat", "4", "-n", "1", "[name=flaky]"+flaky, "[name=stable]echo stable")
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Run with a flaky command should fail, got: %s", output)
+	}
+
+	expected := []string{
+		"flaky   2/4 passed (50.0% failed)",
+		"stable  4/4 passed",
+		"Summary: 1/2 commands passed all 4 runs",
+		"flaky: 1 distinct failure in 2 runs",
+		"2× exit code 1 (runs 2, 4)",
+		"even run",
+	}
+	for _, want := range expected {
+		if !strings.Contains(string(output), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	cmd := exec.Command(binaryPath, "--no-time", `printf "src/a.ts(1,2): error TS1005: ';' expected.\n"; exit 2`)
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Failing command should fail the run, got: %s", output)
+	}
+
+	if !strings.Contains(string(output), "src/a.ts:1:2: error: ';' expected. [TS1005]") {
+		t.Errorf("Report should contain the parsed tsc diagnostic, got:\n%s", output)
+	}
+
+	cmd = exec.Command(binaryPath, "--format", "json", `printf "src/a.ts(1,2): error TS1005: ';' expected.\n"; exit 2`)
+	output, _ = cmd.Output()
+
+	var report struct {
+		Commands []struct {
+			Diagnostics []struct {
+				File string `json:"file"`
+				Rule string `json:"rule"`
+			} `json:"diagnostics"`
+		} `json:"commands"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		t.Fatalf("Invalid JSON report: %v\n%s", err, output)
+	}
+	if len(report.Commands) != 1 || len(report.Commands[0].Diagnostics) != 1 || report.Commands[0].Diagnostics[0].Rule != "TS1005" {
+		t.Errorf("JSON report should contain the tsc diagnostic, got: %s", output)
+	}
+}
+
+func TestMaxOutputLines(t *testing.T) {
+	dir := t.TempDir()
+
+	cmd := exec.Command(binaryPath, "--no-time", "--max-output-lines", "10", `seq 1 500; echo "Error: boom"; seq 501 505; exit 1`)
+	cmd.Dir = dir
+	output, _ := cmd.CombinedOutput()
+
+	for _, want := range []string{"\n1\n", "Error: boom", "\n505\n", "lines omitted (full log at .aifr/logs/1-seq-1-500-echo-Error-boom-seq-501-505-exit-1.log)"} {
+		if !strings.Contains(string(output), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(string(output), "\n250\n") {
+		t.Errorf("Middle of the output should be omitted, got:\n%s", output)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, ".aifr", "logs", "1-seq-1-500-echo-Error-boom-seq-501-505-exit-1.log"))
+	if err != nil || !strings.Contains(string(data), "\n250\n") {
+		t.Errorf("Full log should contain the whole output, got error %v", err)
+	}
+}
+
+func TestLogDir(t *testing.T) {
+	dir := t.TempDir()
+
+	var runDir string
+	for i := 0; i < 3; i++ {
+		cmd := exec.Command(binaryPath, "--no-time", "--log-dir", "logs", "--keep-logs", "2", "[name=check]echo out; echo err >&2; exit 1")
+		cmd.Dir = dir
+		output, _ := cmd.CombinedOutput()
+
+		match := regexp.MustCompile(`log: (logs/\S+)/1-check\.log`).FindStringSubmatch(string(output))
+		if match == nil {
+			t.Fatalf("Expected the report to reference the command log, got:\n%s", output)
+		}
+		runDir = match[1]
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "logs"))
+	if err != nil || len(entries) != 2 {
+		t.Errorf("Expected 2 runs to be kept, got %d entries, error %v", len(entries), err)
+	}
+
+	for name, want := range map[string]string{
+		"1-check.stdout.log": "out\n",
+		"1-check.stderr.log": "err\n",
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, runDir, name))
+		if err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v, want %q", name, data, err, want)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, runDir, "1-check.log"))
+	if err != nil || !strings.Contains(string(data), " stderr | err\n") {
+		t.Errorf("Combined log should mark stderr lines, got %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, runDir, "index.json")); err != nil {
+		t.Errorf("Expected index.json in the run directory: %v", err)
+	}
+}
+
+func TestEnvPrefixIsNotName(t *testing.T) {
+	cmd := exec.Command(binaryPath, "--output", "full", "greeting=hello sh -c 'echo $greeting'")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Command with env prefix failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "hello") {
+		t.Errorf("Env prefix should reach the command, got:\n%s", output)
+	}
+}
+
+func TestStreamLabels(t *testing.T) {
+	cmd := exec.Command(binaryPath, "--stream", "--no-time", "api: echo ready; echo oops >&2", "[name=web]echo listening")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Command failed: %v\n%s", err, output)
+	}
+
+	for _, want := range []string{"api | ready\n", "api ! oops\n", "web | listening\n"} {
+		if !strings.Contains(string(output), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestStreamLongLine(t *testing.T) {
+	// Строка 512 KiB больше лимита bufio.Scanner; раньше чтение останавливалось и процесс мог зависнуть
+	cmd := exec.Command(binaryPath, "--stream", "--no-time", "--output", "full", `head -c 524288 /dev/zero | tr '\0' x; echo; echo "after"`)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Command failed: %v", err)
+	}
+
+	if !strings.Contains(string(output), strings.Repeat("x", 524288)) || !strings.Contains(string(output), "| after\n") {
+		t.Errorf("Expected the long line and the following line to be streamed, got %d bytes", len(output))
+	}
+}
+
+func TestCommandCwdAndEnv(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// env-file отсчитывается от cwd команды
+	if err := os.WriteFile(filepath.Join(dir, "pkg", ".env"), []byte("FROM_FILE=file\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(binaryPath, "--no-time", "--format", "json", "[cwd=pkg,env-file=.env,env=EXTRA=1,shell]echo $FROM_FILE $EXTRA; basename $(pwd); exit 1")
+	cmd.Dir = dir
+	output, _ := cmd.Output()
+
+	var report struct {
+		Commands []struct {
+			Stdout   string   `json:"stdout"`
+			Cwd      string   `json:"cwd"`
+			Env      []string `json:"env"`
+			EnvFiles []string `json:"env_files"`
+		} `json:"commands"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, output)
+	}
+
+	command := report.Commands[0]
+	if command.Stdout != "file 1\npkg\n" {
+		t.Errorf("Expected the command to run in pkg with the env file, got %q", command.Stdout)
+	}
+	if command.Cwd != "pkg" || len(command.Env) != 1 || len(command.EnvFiles) != 1 {
+		t.Errorf("Expected the report to describe the environment, got %+v", command)
+	}
+	if command.Env[0] != "EXTRA" {
+		t.Errorf("Report should contain only names of env variables, got %q", command.Env)
+	}
+}